@@ -11,6 +11,7 @@ require (
 	github.com/stretchr/testify v1.4.0
 	go.uber.org/multierr v1.5.0
 	golang.org/x/sync v0.0.0-20200317015054-43a5402ce75a
+	k8s.io/api v0.18.1
 	k8s.io/apimachinery v0.18.1
 	k8s.io/client-go v0.18.1
 )
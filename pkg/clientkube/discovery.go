@@ -0,0 +1,299 @@
+package clientkube
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+
+	"github.com/bryanl/clientkube/pkg/cluster"
+)
+
+// discoveryTriggers are resources whose changes add or remove resources
+// from discovery.
+var discoveryTriggers = []schema.GroupVersionResource{
+	{Group: "apiextensions.k8s.io", Resource: "customresourcedefinitions"},
+	{Group: "apiregistration.k8s.io", Resource: "apiservices"},
+}
+
+// watchDiscovery watches the discovery triggers available in resourceList,
+// and rediscovers resources when they change until ctx is done. Changes
+// often come in bursts, such as when several CRDs are installed together,
+// so changes within the discovery delay cause one rediscovery.
+func (inf *MemoryStoreInformer) watchDiscovery(ctx context.Context, resourceList cluster.Resources) {
+	changed := make(chan struct{}, 1)
+
+	for _, r := range resourceList {
+		res := r.GroupVersionResource()
+
+		for _, trigger := range discoveryTriggers {
+			if resourceMatches(trigger, res) {
+				inf.goroutine(func() {
+					inf.watchDiscoveryTrigger(ctx, res, changed)
+				})
+			}
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-changed:
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(inf.discoveryDelay):
+			}
+
+			// the changes seen during the delay are covered.
+			select {
+			case <-changed:
+			default:
+			}
+
+			if err := inf.rediscover(); err != nil {
+				inf.logger.Error(err, "rediscover resources")
+			}
+		}
+	}
+}
+
+// watchDiscoveryTrigger notifies changed when objects of a discovery trigger
+// change in a way that can affect discovery. A watch that ends is resumed.
+// A watch that fails is restarted with a list, and changed is notified
+// because changes may have been missed.
+func (inf *MemoryStoreInformer) watchDiscoveryTrigger(
+	ctx context.Context,
+	res schema.GroupVersionResource,
+	changed chan<- struct{}) {
+	logger := inf.logger.WithValues("res", res)
+	backoff := inf.backoff
+
+	wait := func() bool {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(backoff.Step()):
+			return true
+		}
+	}
+
+	resourceVersion := ""
+	// states are the discovery states of the trigger's objects by name.
+	var states map[string]interface{}
+
+	for ctx.Err() == nil {
+		if resourceVersion == "" {
+			list, err := inf.client.List(ctx, res, cluster.ListOptions{})
+			if err != nil {
+				if isPermanentError(err) {
+					logger.Error(err, "unable to watch for discovery changes")
+					return
+				}
+
+				logger.Error(err, "list discovery trigger")
+				if !wait() {
+					return
+				}
+				continue
+			}
+
+			resourceVersion = list.GetResourceVersion()
+
+			states = map[string]interface{}{}
+			for i := range list.Items {
+				states[list.Items[i].GetName()] = discoveryState(&list.Items[i])
+			}
+		}
+
+		w, err := inf.client.Watch(ctx, res, cluster.ListOptions{
+			ListOptions: metav1.ListOptions{ResourceVersion: resourceVersion},
+		})
+		if err != nil {
+			logger.Error(err, "watch discovery trigger")
+			resourceVersion = ""
+			notify(changed)
+			if !wait() {
+				return
+			}
+			continue
+		}
+
+		backoff = inf.backoff
+
+		var ok bool
+		resourceVersion, ok = watchDiscoveryEvents(ctx, w, resourceVersion, states, changed)
+		w.Stop()
+
+		if !ok {
+			resourceVersion = ""
+			notify(changed)
+		}
+	}
+}
+
+// watchDiscoveryEvents notifies changed for each change reported by w that
+// can affect discovery. states are the discovery states of the objects by
+// name, and are updated as they change. It returns the last resource
+// version seen, and false if the watch failed.
+func watchDiscoveryEvents(
+	ctx context.Context,
+	w cluster.Watch,
+	resourceVersion string,
+	states map[string]interface{},
+	changed chan<- struct{}) (string, bool) {
+	for {
+		select {
+		case <-ctx.Done():
+			return resourceVersion, true
+		case e, ok := <-w.ResultChan():
+			if !ok {
+				return resourceVersion, true
+			}
+
+			switch e.Type {
+			case watch.Error:
+				return resourceVersion, false
+			case watch.Added, watch.Modified, watch.Deleted:
+				if isDiscoveryChange(e, states) {
+					notify(changed)
+				}
+			}
+
+			if accessor, err := meta.Accessor(e.Object); err == nil {
+				if rv := accessor.GetResourceVersion(); rv != "" {
+					resourceVersion = rv
+				}
+			}
+		}
+	}
+}
+
+// isDiscoveryChange records the discovery state of an event's object, and
+// returns true unless the event is a modification that left it unchanged.
+func isDiscoveryChange(e watch.Event, states map[string]interface{}) bool {
+	u, ok := e.Object.(*unstructured.Unstructured)
+	if !ok {
+		return true
+	}
+
+	if e.Type == watch.Deleted {
+		delete(states, u.GetName())
+		return true
+	}
+
+	current := discoveryState(u)
+	previous, ok := states[u.GetName()]
+	states[u.GetName()] = current
+
+	return e.Type != watch.Modified || !ok || !equality.Semantic.DeepEqual(previous, current)
+}
+
+// discoveryState returns the parts of a CRD or API service that affect
+// discovery: its spec, its accepted names, and the status of its
+// conditions, since a CRD is served once it is established and an API
+// service once it is available. Other status changes, such as condition
+// messages and transition times, are left out.
+func discoveryState(u *unstructured.Unstructured) map[string]interface{} {
+	spec, _, _ := unstructured.NestedFieldCopy(u.Object, "spec")
+	acceptedNames, _, _ := unstructured.NestedFieldCopy(u.Object, "status", "acceptedNames")
+
+	conditions := map[string]interface{}{}
+	list, _, _ := unstructured.NestedSlice(u.Object, "status", "conditions")
+	for _, item := range list {
+		if condition, ok := item.(map[string]interface{}); ok {
+			conditions[fmt.Sprint(condition["type"])] = condition["status"]
+		}
+	}
+
+	return map[string]interface{}{
+		"spec":          spec,
+		"acceptedNames": acceptedNames,
+		"conditions":    conditions,
+	}
+}
+
+// notify sends to ch without blocking. Notifications that can't be sent are
+// coalesced with the one already pending.
+func notify(ch chan<- struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
+
+// rediscover reads the cluster's resources again. Resources that appeared
+// are informed, and resources that disappeared are stopped and purged from
+// the store.
+func (inf *MemoryStoreInformer) rediscover() error {
+	if invalidator, ok := inf.client.(cluster.Invalidator); ok {
+		invalidator.Invalidate()
+	}
+
+	resourceList, err := inf.client.Resources()
+	if err != nil {
+		return fmt.Errorf("get resources: %w", err)
+	}
+
+	resources, namespaced := inf.selectResources(resourceList)
+
+	inf.mu.Lock()
+
+	var added, removed []schema.GroupVersionResource
+
+	for _, res := range resources {
+		inf.namespaced[res] = namespaced[res]
+
+		if !inf.informable[res] {
+			inf.informable[res] = true
+			added = append(added, res)
+		}
+	}
+
+	for res := range inf.informable {
+		if _, ok := namespaced[res]; !ok {
+			delete(inf.informable, res)
+			delete(inf.namespaced, res)
+			removed = append(removed, res)
+		}
+	}
+
+	var started []schema.GroupVersionResource
+	if !inf.lazy {
+		for _, res := range added {
+			inf.setSyncState(res, SyncStatePending, nil)
+			started = append(started, res)
+		}
+	}
+
+	var stopped []schema.GroupVersionResource
+	for _, res := range removed {
+		if _, ok := inf.syncStates[res]; ok {
+			stopped = append(stopped, res)
+		}
+	}
+
+	inf.mu.Unlock()
+
+	for _, res := range started {
+		inf.logger.Info("informing discovered resource", "res", res)
+		res := res
+		inf.goroutine(func() {
+			inf.startResource(res)
+		})
+	}
+
+	for _, res := range stopped {
+		inf.logger.Info("stopping removed resource", "res", res)
+		inf.stopResource(res)
+	}
+
+	return nil
+}
@@ -0,0 +1,215 @@
+package clientkube
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+
+	"github.com/bryanl/clientkube/pkg/cluster"
+	"github.com/bryanl/clientkube/pkg/mocks"
+)
+
+type invalidatingClient struct {
+	*mocks.MockClient
+	invalidated int32
+}
+
+func (c *invalidatingClient) Invalidate() {
+	atomic.AddInt32(&c.invalidated, 1)
+}
+
+func TestMemoryStoreInformer_dynamicDiscovery(t *testing.T) {
+	crds := schema.GroupVersionResource{
+		Group:    "apiextensions.k8s.io",
+		Version:  "v1",
+		Resource: "customresourcedefinitions",
+	}
+	res1 := schema.GroupVersionResource{Group: "group1", Version: "version", Resource: "resource"}
+	res2 := schema.GroupVersionResource{Group: "group2", Version: "version", Resource: "resource"}
+
+	newAPIResource := func(name string) metav1.APIResource {
+		return metav1.APIResource{
+			Name:       name,
+			Namespaced: true,
+			Kind:       "Kind",
+			Verbs:      metav1.Verbs{"list", "watch"},
+		}
+	}
+
+	newList := func(resourceVersion string, items ...unstructured.Unstructured) *unstructured.UnstructuredList {
+		list := &unstructured.UnstructuredList{Items: items}
+		list.SetResourceVersion(resourceVersion)
+		return list
+	}
+
+	object := unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": res1.GroupVersion().String(),
+			"kind":       "Kind",
+			"metadata": map[string]interface{}{
+				"name":            "object",
+				"namespace":       "default",
+				"resourceVersion": "2",
+			},
+		},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	newWatch := func() (*mocks.MockWatch, chan watch.Event) {
+		ch := make(chan watch.Event)
+		w := mocks.NewMockWatch(ctrl)
+		w.EXPECT().ResultChan().Return(ch).AnyTimes()
+		w.EXPECT().Stop().AnyTimes()
+		return w, ch
+	}
+
+	crdTriggerWatch, crdCh := newWatch()
+	res1Watch, _ := newWatch()
+	res2Watch, _ := newWatch()
+
+	client := &invalidatingClient{MockClient: mocks.NewMockClient(ctrl)}
+
+	gomock.InOrder(
+		client.EXPECT().Resources().Return(cluster.Resources{
+			newResource(crds.GroupVersion(), newAPIResource(crds.Resource)),
+			newResource(res1.GroupVersion(), newAPIResource(res1.Resource)),
+		}, nil),
+		client.EXPECT().Resources().Return(cluster.Resources{
+			newResource(crds.GroupVersion(), newAPIResource(crds.Resource)),
+			newResource(res2.GroupVersion(), newAPIResource(res2.Resource)),
+		}, nil),
+	)
+
+	client.EXPECT().List(gomock.Any(), crds, cluster.ListOptions{}).Return(newList("1"), nil)
+	client.EXPECT().Watch(gomock.Any(), crds, gomock.Any()).Return(crdTriggerWatch, nil)
+	client.EXPECT().List(gomock.Any(), res1, cluster.ListOptions{}).Return(newList("2", object), nil)
+	client.EXPECT().Watch(gomock.Any(), res1, gomock.Any()).Return(res1Watch, nil)
+
+	store := NewMemoryStore()
+	// CRDs are watched for discovery changes even if they aren't cached.
+	msi := NewInformer(client,
+		WithStore(store),
+		WithDynamicDiscovery(),
+		WithDiscoveryDelay(10*time.Millisecond),
+		WithExcludedResources(crds))
+	require.NoError(t, msi.Start(ctx))
+	defer func() {
+		require.NoError(t, msi.Stop())
+	}()
+
+	require.NoError(t, msi.WaitForSync(ctx))
+	require.True(t, msi.HasSynced(res1))
+
+	// a CRD change adds res2 and removes res1.
+	client.EXPECT().List(gomock.Any(), res2, cluster.ListOptions{}).Return(newList("3"), nil)
+	client.EXPECT().Watch(gomock.Any(), res2, gomock.Any()).Return(res2Watch, nil)
+
+	crd := unstructured.Unstructured{}
+	crd.SetName("resources.group2")
+	crd.SetResourceVersion("3")
+	crdCh <- watch.Event{Type: watch.Added, Object: &crd}
+
+	require.Eventually(t, func() bool {
+		return msi.HasSynced(res2) && !msi.HasSynced(res1)
+	}, 5*time.Second, 10*time.Millisecond)
+
+	require.Equal(t, int32(1), atomic.LoadInt32(&client.invalidated))
+
+	list, err := store.List(res1, cluster.ListOptions{})
+	require.NoError(t, err)
+	require.Empty(t, list.Items)
+
+	for _, status := range msi.SyncStatus() {
+		require.NotEqual(t, res1, status.Resource)
+	}
+}
+
+func TestMemoryStoreInformer_dynamicDiscovery_coalesced(t *testing.T) {
+	crds := schema.GroupVersionResource{
+		Group:    "apiextensions.k8s.io",
+		Version:  "v1",
+		Resource: "customresourcedefinitions",
+	}
+
+	newCRD := func(name, resourceVersion, established string) *unstructured.Unstructured {
+		crd := &unstructured.Unstructured{Object: map[string]interface{}{
+			"spec": map[string]interface{}{"group": "example.com"},
+			"status": map[string]interface{}{
+				"conditions": []interface{}{
+					map[string]interface{}{
+						"type":               "Established",
+						"status":             established,
+						"lastTransitionTime": resourceVersion,
+					},
+				},
+			},
+		}}
+		crd.SetName(name)
+		crd.SetResourceVersion(resourceVersion)
+		return crd
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ch := make(chan watch.Event)
+	crdWatch := mocks.NewMockWatch(ctrl)
+	crdWatch.EXPECT().ResultChan().Return(ch).AnyTimes()
+	crdWatch.EXPECT().Stop().AnyTimes()
+
+	client := &invalidatingClient{MockClient: mocks.NewMockClient(ctrl)}
+	client.EXPECT().Resources().Return(cluster.Resources{
+		newResource(crds.GroupVersion(), metav1.APIResource{
+			Name:  crds.Resource,
+			Kind:  "CustomResourceDefinition",
+			Verbs: metav1.Verbs{"list", "watch"},
+		}),
+	}, nil).AnyTimes()
+
+	list := &unstructured.UnstructuredList{}
+	list.SetResourceVersion("1")
+	client.EXPECT().List(gomock.Any(), crds, cluster.ListOptions{}).Return(list, nil)
+	client.EXPECT().Watch(gomock.Any(), crds, gomock.Any()).Return(crdWatch, nil)
+
+	delay := 100 * time.Millisecond
+	msi := NewInformer(client,
+		WithDynamicDiscovery(),
+		WithDiscoveryDelay(delay),
+		WithExcludedResources(crds))
+	require.NoError(t, msi.Start(ctx))
+	defer func() {
+		require.NoError(t, msi.Stop())
+	}()
+
+	// a burst of changes causes one rediscovery.
+	ch <- watch.Event{Type: watch.Added, Object: newCRD("a", "2", "False")}
+	ch <- watch.Event{Type: watch.Added, Object: newCRD("b", "3", "False")}
+	ch <- watch.Event{Type: watch.Modified, Object: newCRD("a", "4", "True")}
+	ch <- watch.Event{Type: watch.Modified, Object: newCRD("b", "5", "True")}
+
+	require.Eventually(t, func() bool {
+		return atomic.LoadInt32(&client.invalidated) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// a status change that doesn't change a condition's status is ignored.
+	ch <- watch.Event{Type: watch.Modified, Object: newCRD("a", "6", "True")}
+
+	time.Sleep(3 * delay)
+	require.Equal(t, int32(1), atomic.LoadInt32(&client.invalidated))
+}
@@ -0,0 +1,380 @@
+package clientkube
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/apimachinery/pkg/watch"
+
+	"github.com/bryanl/clientkube/pkg/cluster"
+)
+
+// ResourceEventHandler handles changes to the objects of a resource. Any of
+// the functions can be nil.
+type ResourceEventHandler struct {
+	// OnAdd is called when an object is added.
+	OnAdd func(object *unstructured.Unstructured)
+	// OnUpdate is called when an object is updated. old is the previous
+	// version of the object.
+	OnUpdate func(old, new *unstructured.Unstructured)
+	// OnDelete is called when an object is deleted.
+	OnDelete func(object *unstructured.Unstructured)
+}
+
+// EventHandlerRegistration identifies a registered event handler.
+type EventHandlerRegistration struct {
+	id  string
+	res schema.GroupVersionResource
+}
+
+// Resource returns the group/version/resource the handler is registered for.
+func (r *EventHandlerRegistration) Resource() schema.GroupVersionResource {
+	return r.res
+}
+
+// AddEventHandler registers a handler for changes to a resource in the
+// memory store. The handler is first called with OnAdd for the objects
+// already in the store. Each handler runs in its own goroutine, so a slow
+// handler doesn't block the store or other handlers.
+func (inf *MemoryStoreInformer) AddEventHandler(
+	res schema.GroupVersionResource,
+	handler ResourceEventHandler) (*EventHandlerRegistration, error) {
+	if inf.isStopped() {
+		return nil, errInformerStopped
+	}
+
+	inf.touch(res)
+
+	w, err := inf.store.Watch(res, cluster.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("create store watcher: %w", err)
+	}
+
+	reg := &EventHandlerRegistration{
+		id:  rand.String(16),
+		res: res,
+	}
+
+	runner := newEventHandlerRunner(res, handler, w, inf.store, inf.backoff.Duration, inf.logger)
+
+	inf.mu.Lock()
+	defer inf.mu.Unlock()
+
+	if inf.isStopped() {
+		w.Stop()
+		return nil, errInformerStopped
+	}
+
+	inf.eventHandlers[reg.id] = runner
+
+	inf.goroutine(func() {
+		runner.pump(w)
+	})
+	inf.goroutine(func() {
+		runner.dispatch(inf.goroutine)
+	})
+
+	return reg, nil
+}
+
+// RemoveEventHandler removes a handler registered with AddEventHandler. A
+// handler call that is in progress is allowed to finish.
+func (inf *MemoryStoreInformer) RemoveEventHandler(reg *EventHandlerRegistration) error {
+	if reg == nil {
+		return fmt.Errorf("registration is nil")
+	}
+
+	inf.mu.Lock()
+	runner, ok := inf.eventHandlers[reg.id]
+	delete(inf.eventHandlers, reg.id)
+	inf.mu.Unlock()
+
+	if !ok {
+		return fmt.Errorf("event handler for %s is not registered", reg.res)
+	}
+
+	runner.stop()
+
+	return nil
+}
+
+// eventHandlerRunner delivers store events to a handler. Events from the
+// store watch are queued without bound so the store is never blocked by
+// the handler. If the store ends the watch, such as when it overflows, the
+// runner watches again and reconciles the handler with the store.
+type eventHandlerRunner struct {
+	res           schema.GroupVersionResource
+	handler       ResourceEventHandler
+	store         cluster.Store
+	retryInterval time.Duration
+	logger        logr.Logger
+
+	// known is the last version of each object delivered to the handler.
+	// It supplies the previous object for updates.
+	known map[storeKey]*unstructured.Unstructured
+
+	watch   cluster.Watch
+	queue   []watch.Event
+	closed  bool
+	stopped bool
+	done    chan struct{}
+	mu      sync.Mutex
+	cond    *sync.Cond
+}
+
+func newEventHandlerRunner(
+	res schema.GroupVersionResource,
+	handler ResourceEventHandler,
+	w cluster.Watch,
+	store cluster.Store,
+	retryInterval time.Duration,
+	logger logr.Logger) *eventHandlerRunner {
+	r := eventHandlerRunner{
+		res:           res,
+		handler:       handler,
+		store:         store,
+		retryInterval: retryInterval,
+		watch:         w,
+		logger:        logger.WithValues("res", res),
+		known:         map[storeKey]*unstructured.Unstructured{},
+		done:          make(chan struct{}),
+	}
+	r.cond = sync.NewCond(&r.mu)
+
+	return &r
+}
+
+// stop stops the runner. It doesn't wait for the handler to return, so a
+// handler can remove itself.
+func (r *eventHandlerRunner) stop() {
+	r.mu.Lock()
+	if r.stopped {
+		r.mu.Unlock()
+		return
+	}
+
+	r.stopped = true
+	close(r.done)
+	r.cond.Broadcast()
+	w := r.watch
+	r.mu.Unlock()
+
+	w.Stop()
+}
+
+// pump moves events from a store watch to the queue.
+func (r *eventHandlerRunner) pump(w cluster.Watch) {
+	for e := range w.ResultChan() {
+		r.mu.Lock()
+		r.queue = append(r.queue, e)
+		r.cond.Signal()
+		r.mu.Unlock()
+	}
+
+	r.mu.Lock()
+	r.closed = true
+	r.cond.Signal()
+	r.mu.Unlock()
+}
+
+// next returns the next queued event. It returns false when the runner is
+// stopped or the watch has ended.
+func (r *eventHandlerRunner) next() (watch.Event, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for len(r.queue) == 0 && !r.closed && !r.stopped {
+		r.cond.Wait()
+	}
+
+	if r.stopped || len(r.queue) == 0 {
+		return watch.Event{}, false
+	}
+
+	e := r.queue[0]
+	r.queue[0] = watch.Event{}
+	r.queue = r.queue[1:]
+
+	return e, true
+}
+
+func (r *eventHandlerRunner) isStopped() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.stopped
+}
+
+// dispatch calls the handler with the objects in the store, and then with
+// queued events. The watch is created before the store is listed, so
+// queued events that are already reflected in the list are skipped. When
+// the watch ends, a new one is created with spawn pumping its events, and
+// the handler is reconciled with the store again.
+func (r *eventHandlerRunner) dispatch(spawn func(fn func()) bool) {
+	for {
+		if err := r.reconcile(); err != nil {
+			r.logger.Error(err, "list store for event handler")
+		}
+
+		r.deliver()
+
+		if !r.rewatch(spawn) {
+			return
+		}
+	}
+}
+
+// reconcile makes what the handler has seen match the store. Objects that
+// are new or changed are added or updated, and objects that are gone are
+// deleted.
+func (r *eventHandlerRunner) reconcile() error {
+	list, err := r.store.List(r.res, cluster.ListOptions{})
+	if err != nil {
+		return err
+	}
+
+	listed := map[storeKey]bool{}
+
+	for i := range list.Items {
+		if r.isStopped() {
+			return nil
+		}
+
+		object := list.Items[i].DeepCopy()
+		listed[storeKeyFor(object)] = true
+
+		r.handle(watch.Modified, object)
+	}
+
+	for key, object := range r.known {
+		if r.isStopped() {
+			return nil
+		}
+
+		if listed[key] {
+			continue
+		}
+
+		delete(r.known, key)
+
+		if r.handler.OnDelete != nil {
+			r.handler.OnDelete(object)
+		}
+	}
+
+	return nil
+}
+
+// deliver calls the handler with queued events until the watch ends or the
+// runner is stopped. A watch that reports an error is stopped.
+func (r *eventHandlerRunner) deliver() {
+	for {
+		e, ok := r.next()
+		if !ok {
+			return
+		}
+
+		if e.Type == watch.Error {
+			r.logger.Info("event handler watch failed; watching again", "status", e.Object)
+
+			r.mu.Lock()
+			w := r.watch
+			r.mu.Unlock()
+
+			w.Stop()
+			continue
+		}
+
+		object, ok := e.Object.(*unstructured.Unstructured)
+		if !ok {
+			continue
+		}
+
+		r.handle(e.Type, object)
+	}
+}
+
+// rewatch replaces an ended watch, retrying until it succeeds or the runner
+// is stopped. It returns false if the runner is stopped.
+func (r *eventHandlerRunner) rewatch(spawn func(fn func()) bool) bool {
+	for {
+		if r.isStopped() {
+			return false
+		}
+
+		w, err := r.store.Watch(r.res, cluster.ListOptions{})
+		if err == nil {
+			r.mu.Lock()
+			if r.stopped {
+				r.mu.Unlock()
+				w.Stop()
+				return false
+			}
+
+			r.watch = w
+			r.queue = nil
+			r.closed = false
+			r.mu.Unlock()
+
+			if !spawn(func() { r.pump(w) }) {
+				w.Stop()
+				return false
+			}
+
+			return true
+		}
+
+		r.logger.Error(err, "create store watcher for event handler")
+
+		select {
+		case <-r.done:
+			return false
+		case <-time.After(r.retryInterval):
+		}
+	}
+}
+
+func (r *eventHandlerRunner) handle(eventType watch.EventType, object *unstructured.Unstructured) {
+	key := storeKeyFor(object)
+	old, isKnown := r.known[key]
+
+	switch eventType {
+	case watch.Added, watch.Modified:
+		// the event is already reflected in what the handler has seen.
+		if isKnown && old.GetResourceVersion() != "" &&
+			(old.GetResourceVersion() == object.GetResourceVersion() ||
+				isResourceVersionNewer(old.GetResourceVersion(), object.GetResourceVersion())) {
+			return
+		}
+
+		r.known[key] = object
+
+		if !isKnown {
+			if r.handler.OnAdd != nil {
+				r.handler.OnAdd(object)
+			}
+			return
+		}
+
+		if r.handler.OnUpdate != nil {
+			r.handler.OnUpdate(old, object)
+		}
+	case watch.Deleted:
+		// the handler never saw the object, or it was recreated after this
+		// deletion.
+		if !isKnown || isResourceVersionNewer(old.GetResourceVersion(), object.GetResourceVersion()) {
+			return
+		}
+
+		delete(r.known, key)
+
+		if r.handler.OnDelete != nil {
+			r.handler.OnDelete(object)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package clientkube
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+
+	"github.com/bryanl/clientkube/pkg/cluster"
+	"github.com/bryanl/clientkube/pkg/mocks"
+)
+
+func TestMemoryStoreInformer_AddEventHandler(t *testing.T) {
+	res := schema.GroupVersionResource{
+		Group:    "group",
+		Version:  "version",
+		Resource: "resource",
+	}
+
+	newObject := func(name, resourceVersion string) *unstructured.Unstructured {
+		return &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"apiVersion": res.GroupVersion().String(),
+				"kind":       "Resource",
+				"metadata": map[string]interface{}{
+					"name":            name,
+					"namespace":       "default",
+					"resourceVersion": resourceVersion,
+				},
+			},
+		}
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := NewMemoryStore()
+	store.Add(res, newObject("a", "1"))
+
+	msi := NewInformer(mocks.NewMockClient(ctrl), WithStore(store))
+
+	calls := make(chan string, 10)
+	reg, err := msi.AddEventHandler(res, ResourceEventHandler{
+		OnAdd: func(object *unstructured.Unstructured) {
+			calls <- "add " + object.GetName() + "@" + object.GetResourceVersion()
+		},
+		OnUpdate: func(old, new *unstructured.Unstructured) {
+			calls <- "update " + old.GetName() + "@" + old.GetResourceVersion() + " " + new.GetResourceVersion()
+		},
+		OnDelete: func(object *unstructured.Unstructured) {
+			calls <- "delete " + object.GetName() + "@" + object.GetResourceVersion()
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, res, reg.Resource())
+
+	// a handler that never returns doesn't block the store or other handlers.
+	blocked := make(chan struct{})
+	defer close(blocked)
+	slowReg, err := msi.AddEventHandler(res, ResourceEventHandler{
+		OnAdd: func(*unstructured.Unstructured) {
+			<-blocked
+		},
+	})
+	require.NoError(t, err)
+
+	next := func() string {
+		select {
+		case call := <-calls:
+			return call
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for handler")
+			return ""
+		}
+	}
+
+	require.Equal(t, "add a@1", next())
+
+	store.Update(res, newObject("a", "2"))
+	require.Equal(t, "update a@1 2", next())
+
+	store.Add(res, newObject("b", "3"))
+	require.Equal(t, "add b@3", next())
+
+	store.Delete(res, newObject("a", "4"))
+	require.Equal(t, "delete a@4", next())
+
+	require.NoError(t, msi.RemoveEventHandler(reg))
+	require.NoError(t, msi.RemoveEventHandler(slowReg))
+	require.Error(t, msi.RemoveEventHandler(reg))
+
+	store.Update(res, newObject("b", "5"))
+
+	select {
+	case call := <-calls:
+		t.Fatalf("unexpected call after handler was removed: %s", call)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+// failingWatchStore returns a controllable watch for its first Watch, and
+// watches the memory store afterwards.
+type failingWatchStore struct {
+	*MemoryStore
+	first *watch.FakeWatcher
+	once  sync.Once
+}
+
+func (s *failingWatchStore) Watch(res schema.GroupVersionResource, options cluster.ListOptions) (cluster.Watch, error) {
+	var w cluster.Watch
+	s.once.Do(func() {
+		w = s.first
+	})
+
+	if w != nil {
+		return w, nil
+	}
+
+	return s.MemoryStore.Watch(res, options)
+}
+
+func TestMemoryStoreInformer_AddEventHandler_watchEnds(t *testing.T) {
+	res := schema.GroupVersionResource{
+		Group:    "group",
+		Version:  "version",
+		Resource: "resource",
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := &failingWatchStore{
+		MemoryStore: NewMemoryStore(),
+		first:       watch.NewFakeWithChanSize(1, false),
+	}
+	store.Add(res, newVersionedObject("a", "1"))
+	store.Add(res, newVersionedObject("c", "2"))
+
+	msi := NewInformer(mocks.NewMockClient(ctrl), WithStore(store))
+
+	calls := make(chan string, 10)
+	reg, err := msi.AddEventHandler(res, ResourceEventHandler{
+		OnAdd: func(object *unstructured.Unstructured) {
+			calls <- "add " + object.GetName() + "@" + object.GetResourceVersion()
+		},
+		OnUpdate: func(old, new *unstructured.Unstructured) {
+			calls <- "update " + old.GetName() + "@" + old.GetResourceVersion() + " " + new.GetResourceVersion()
+		},
+		OnDelete: func(object *unstructured.Unstructured) {
+			calls <- "delete " + object.GetName() + "@" + object.GetResourceVersion()
+		},
+	})
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, msi.RemoveEventHandler(reg))
+	}()
+
+	next := func() string {
+		select {
+		case call := <-calls:
+			return call
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for handler")
+			return ""
+		}
+	}
+
+	require.Equal(t, "add a@1", next())
+	require.Equal(t, "add c@2", next())
+
+	// changes the handler's watch misses before it fails.
+	store.Update(res, newVersionedObject("a", "3"))
+	store.Add(res, newVersionedObject("b", "4"))
+	store.Delete(res, newVersionedObject("c", "5"))
+
+	store.first.Error(&apierrors.NewInternalError(errors.New("watch failed")).ErrStatus)
+
+	// the handler is reconciled with the store, and keeps receiving events.
+	require.Equal(t, "update a@1 3", next())
+	require.Equal(t, "add b@4", next())
+	require.Equal(t, "delete c@2", next())
+
+	store.Update(res, newVersionedObject("b", "6"))
+	require.Equal(t, "update b@4 6", next())
+}
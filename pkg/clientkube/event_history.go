@@ -0,0 +1,133 @@
+package clientkube
+
+import (
+	"fmt"
+	"strconv"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// eventHistory tracks the latest resource version for each resource and
+// buffers recent events, so watches can resume from a resource version.
+// It is not safe for concurrent use; MemoryStore guards it with its lock.
+type eventHistory struct {
+	size      int
+	resources map[schema.GroupVersionResource]*resourceHistory
+}
+
+type resourceHistory struct {
+	latest uint64
+	// compacted is the newest resource version evicted from events. Watches
+	// older than it can't be resumed.
+	compacted uint64
+	events    []historyEvent
+}
+
+type historyEvent struct {
+	event
+
+	resourceVersion uint64
+}
+
+func newEventHistory(size int) *eventHistory {
+	h := eventHistory{
+		size:      size,
+		resources: map[schema.GroupVersionResource]*resourceHistory{},
+	}
+
+	return &h
+}
+
+// record records an event. Events for objects without a numeric resource
+// version are not buffered.
+func (h *eventHistory) record(e event) {
+	accessor, err := meta.Accessor(e.Object)
+	if err != nil {
+		return
+	}
+
+	rv, ok := parseResourceVersion(accessor.GetResourceVersion())
+	if !ok {
+		return
+	}
+
+	rh, ok := h.resources[e.Resource]
+	if !ok {
+		rh = &resourceHistory{}
+		h.resources[e.Resource] = rh
+	}
+
+	if rv > rh.latest {
+		rh.latest = rv
+	}
+
+	if h.size <= 0 {
+		rh.compacted = rh.latest
+		return
+	}
+
+	rh.events = append(rh.events, historyEvent{event: e, resourceVersion: rv})
+
+	for len(rh.events) > h.size {
+		if evicted := rh.events[0].resourceVersion; evicted > rh.compacted {
+			rh.compacted = evicted
+		}
+
+		rh.events[0] = historyEvent{}
+		rh.events = rh.events[1:]
+	}
+}
+
+// resourceVersion returns the latest resource version for a resource, or
+// an empty string if none has been seen.
+func (h *eventHistory) resourceVersion(res schema.GroupVersionResource) string {
+	rh, ok := h.resources[res]
+	if !ok || rh.latest == 0 {
+		return ""
+	}
+
+	return strconv.FormatUint(rh.latest, 10)
+}
+
+// since returns copies of the buffered events newer than a resource
+// version. An empty or "0" resource version means now, and returns no
+// events.
+func (h *eventHistory) since(res schema.GroupVersionResource, resourceVersion string) ([]event, error) {
+	if resourceVersion == "" || resourceVersion == "0" {
+		return nil, nil
+	}
+
+	rv, ok := parseResourceVersion(resourceVersion)
+	if !ok {
+		return nil, apierrors.NewBadRequest(fmt.Sprintf("invalid resource version %q", resourceVersion))
+	}
+
+	rh, ok := h.resources[res]
+	if !ok {
+		return nil, nil
+	}
+
+	if rv < rh.compacted {
+		return nil, apierrors.NewResourceExpired(
+			fmt.Sprintf("too old resource version: %d (%d)", rv, rh.compacted))
+	}
+
+	var events []event
+	for _, he := range rh.events {
+		if he.resourceVersion <= rv {
+			continue
+		}
+
+		e := he.event
+		e.Object = e.Object.DeepCopyObject()
+		if e.Old != nil {
+			e.Old = e.Old.DeepCopyObject()
+		}
+
+		events = append(events, e)
+	}
+
+	return events, nil
+}
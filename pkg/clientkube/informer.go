@@ -2,24 +2,35 @@ package clientkube
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"runtime"
 	"sync"
+	"time"
 
 	"github.com/go-logr/logr"
-	"golang.org/x/sync/errgroup"
 	"golang.org/x/sync/semaphore"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/apimachinery/pkg/watch"
 
 	"github.com/bryanl/clientkube/internal/stringutil"
 	"github.com/bryanl/clientkube/pkg/cluster"
 )
 
+// watchDescriptor describes a watch of the cluster that is switched to the
+// store once its resource is synced.
 type watchDescriptor struct {
-	watch   *UpdatableWatcher
+	res     schema.GroupVersionResource
 	options cluster.ListOptions
+	// initial sends the watch's initial events, if it has any.
+	initial *initialEventsWatch
 }
 
 // Informer represents a cluster MemoryStoreInformer.
@@ -39,12 +50,63 @@ type MemoryStoreInformer struct {
 	client           cluster.Client
 	synced           map[schema.GroupVersionResource]bool
 	apiWatches       map[schema.GroupVersionResource]cluster.Watch
-	watchDescriptors map[schema.GroupVersionResource]watchDescriptor
+	watchDescriptors map[*UpdatableWatcher]watchDescriptor
+	eventHandlers    map[string]*eventHandlerRunner
+	syncStates       map[schema.GroupVersionResource]syncState
 	store            cluster.Store
 	logger           logr.Logger
+	backoff          wait.Backoff
+	asyncStart       bool
+	filter           resourceFilter
+
+	// informable are the resources the informer can cache.
+	informable map[schema.GroupVersionResource]bool
+	// resourceContexts are the contexts of informed resources, and
+	// resourceCancels cancel them.
+	resourceContexts map[schema.GroupVersionResource]context.Context
+	resourceCancels  map[schema.GroupVersionResource]context.CancelFunc
+
+	// lazy informers start caching a resource when it is first read, and
+	// stop once it has been idle for idleTimeout.
+	lazy          bool
+	idleTimeout   time.Duration
+	activeWatches map[schema.GroupVersionResource]int
+
+	// lastAccess is guarded by accessMu rather than mu, so reads don't
+	// take the informer's write lock.
+	lastAccess map[schema.GroupVersionResource]time.Time
+	accessMu   sync.Mutex
+
+	// namespaces limits namespaced resources to these namespaces. If it is
+	// empty, resources are cached across the cluster.
+	namespaces    []string
+	clusterScoped []schema.GroupVersionResource
+	namespaced    map[schema.GroupVersionResource]bool
+
+	// dynamicDiscovery rediscovers resources when CRDs or API services
+	// change. Changes within discoveryDelay are coalesced.
+	dynamicDiscovery bool
+	discoveryDelay   time.Duration
+
+	// syncChanged is closed and replaced when a sync state changes.
+	syncChanged chan struct{}
+
+	// ctx is the context for watches. It is canceled when the informer is
+	// stopped.
+	ctx    context.Context
+	cancel context.CancelFunc
 
 	mu  sync.RWMutex
 	sem *semaphore.Weighted
+
+	// updatableWatchers are the watches returned by Watch.
+	updatableWatchers map[*UpdatableWatcher]bool
+
+	// goroutines are the goroutines Stop waits for.
+	goroutines  sync.WaitGroup
+	stopTimeout time.Duration
+	stopped     bool
+	lifecycleMu sync.Mutex
 }
 
 var _ Informer = &MemoryStoreInformer{}
@@ -57,13 +119,33 @@ func NewInformer(client cluster.Client, optionList ...Option) *MemoryStoreInform
 	maxWorkers := runtime.GOMAXPROCS(0)
 
 	i := MemoryStoreInformer{
-		client:           client,
-		synced:           map[schema.GroupVersionResource]bool{},
-		apiWatches:       map[schema.GroupVersionResource]cluster.Watch{},
-		watchDescriptors: map[schema.GroupVersionResource]watchDescriptor{},
-		store:            opts.store,
-		logger:           opts.logger.WithValues("component", "MemoryStoreInformer"),
-		sem:              semaphore.NewWeighted(int64(maxWorkers)),
+		client:            client,
+		synced:            map[schema.GroupVersionResource]bool{},
+		apiWatches:        map[schema.GroupVersionResource]cluster.Watch{},
+		watchDescriptors:  map[*UpdatableWatcher]watchDescriptor{},
+		eventHandlers:     map[string]*eventHandlerRunner{},
+		syncStates:        map[schema.GroupVersionResource]syncState{},
+		store:             opts.store,
+		logger:            opts.logger.WithValues("component", "MemoryStoreInformer"),
+		backoff:           opts.backoff,
+		asyncStart:        opts.asyncStart,
+		filter:            opts.filter,
+		informable:        map[schema.GroupVersionResource]bool{},
+		resourceContexts:  map[schema.GroupVersionResource]context.Context{},
+		resourceCancels:   map[schema.GroupVersionResource]context.CancelFunc{},
+		lazy:              opts.lazy,
+		idleTimeout:       opts.idleTimeout,
+		lastAccess:        map[schema.GroupVersionResource]time.Time{},
+		activeWatches:     map[schema.GroupVersionResource]int{},
+		namespaces:        opts.namespaces,
+		clusterScoped:     opts.clusterScoped,
+		namespaced:        map[schema.GroupVersionResource]bool{},
+		dynamicDiscovery:  opts.dynamicDiscovery,
+		discoveryDelay:    opts.discoveryDelay,
+		updatableWatchers: map[*UpdatableWatcher]bool{},
+		stopTimeout:       opts.stopTimeout,
+		syncChanged:       make(chan struct{}),
+		sem:               semaphore.NewWeighted(int64(maxWorkers)),
 	}
 
 	if i.store == nil {
@@ -73,84 +155,319 @@ func NewInformer(client cluster.Client, optionList ...Option) *MemoryStoreInform
 	return &i
 }
 
-// Start starts the informer. Afterwards, you should call Stop.
+// Start starts the informer. Afterwards, you should call Stop. Watches
+// run until the informer is stopped or ctx is canceled. Start waits for
+// every resource to be listed once unless the informer was created with
+// WithAsyncStart, in which case WaitForSync can be used to wait. A resource
+// that fails does not fail Start: forbidden resources are skipped, and
+// other failures are retried in the background. Resources that are not
+// synced are read from the cluster. An informer created with
+// WithLazyInforming doesn't cache anything until a resource is read.
 func (inf *MemoryStoreInformer) Start(ctx context.Context) error {
+	if inf.isStopped() {
+		return errInformerStopped
+	}
+
+	inf.mu.Lock()
+	inf.ctx, inf.cancel = context.WithCancel(ctx)
+	inf.mu.Unlock()
+
 	resourceList, err := inf.client.Resources()
 	if err != nil {
 		return fmt.Errorf("get resources: %w", err)
 	}
 
-	var g errgroup.Group
+	resources, namespaced := inf.selectResources(resourceList)
 
-	for i := range resourceList {
-		i := i
+	inf.mu.Lock()
+	for _, res := range resources {
+		inf.namespaced[res] = namespaced[res]
+
+		inf.informable[res] = true
 
+		if !inf.lazy {
+			inf.setSyncState(res, SyncStatePending, nil)
+		}
+	}
+	inf.mu.Unlock()
+
+	if inf.dynamicDiscovery {
+		inf.goroutine(func() {
+			inf.watchDiscovery(inf.ctx, resourceList)
+		})
+	}
+
+	if inf.lazy {
+		inf.goroutine(func() {
+			inf.stopIdleResources(inf.ctx)
+		})
+		return nil
+	}
+
+	run := func() {
+		var wg sync.WaitGroup
+
+		for i := range resources {
+			res := resources[i]
+
+			wg.Add(1)
+			if !inf.goroutine(func() {
+				defer wg.Done()
+				inf.startResource(res)
+			}) {
+				wg.Done()
+			}
+		}
+
+		wg.Wait()
+	}
+
+	if inf.asyncStart {
+		inf.goroutine(run)
+		return nil
+	}
+
+	run()
+
+	return nil
+}
+
+// selectResources returns the resources the informer can cache, and
+// whether each is namespaced.
+func (inf *MemoryStoreInformer) selectResources(
+	resourceList cluster.Resources) ([]schema.GroupVersionResource, map[schema.GroupVersionResource]bool) {
+	var resources []schema.GroupVersionResource
+	namespaced := map[schema.GroupVersionResource]bool{}
+
+	for i := range resourceList {
 		// only work with resources that can be watched
 		if !stringutil.Contains(resourceList[i].Verbs(), "watch") {
 			continue
 		}
 
-		g.Go(func() error {
-			if err := inf.sem.Acquire(ctx, 1); err != nil {
-				return fmt.Errorf("acquire semaphore: %w", err)
-			}
+		if !inf.filter.matches(resourceList[i]) {
+			continue
+		}
+
+		if !inf.isScopeAllowed(resourceList[i]) {
+			continue
+		}
+
+		res := resourceList[i].GroupVersionResource()
+		resources = append(resources, res)
+		namespaced[res] = resourceList[i].IsNamespaced()
+	}
+
+	return resources, namespaced
+}
+
+// startResource informs a resource. If it fails with an error that is
+// not permanent, it is retried in the background with backoff.
+func (inf *MemoryStoreInformer) startResource(res schema.GroupVersionResource) {
+	ctx := inf.resourceContext(res)
+
+	err := inf.informResource(ctx, res)
+	if err == nil || ctx.Err() != nil {
+		return
+	}
+
+	if isPermanentError(err) {
+		inf.logger.Info("skipping resource", "res", res, "reason", err.Error())
+		return
+	}
 
-			defer inf.sem.Release(1)
+	inf.logger.Error(err, "inform resource; retrying", "res", res)
 
-			res := resourceList[i].GroupVersionResource()
-			w, err := inf.setupWatch(ctx, res)
-			if err != nil {
-				return fmt.Errorf("setup watch %s: %w", res.String(), err)
+	inf.goroutine(func() {
+		backoff := inf.backoff
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(backoff.Step()):
 			}
 
-			go inf.handleWatch(res, w)
-			if err := inf.SetSynced(res, w); err != nil {
-				return fmt.Errorf("sync watc %s: %w", res.String(), err)
+			err := inf.informResource(ctx, res)
+			if err == nil || ctx.Err() != nil || isPermanentError(err) {
+				return
 			}
 
-			return nil
-		})
+			inf.logger.Error(err, "inform resource; retrying", "res", res)
+		}
+	})
+}
+
+// informResource lists and watches a resource, and marks it as synced.
+// If it fails, the resource is marked as failed.
+func (inf *MemoryStoreInformer) informResource(ctx context.Context, res schema.GroupVersionResource) error {
+	if err := inf.sem.Acquire(ctx, 1); err != nil {
+		return fmt.Errorf("acquire semaphore: %w", err)
 	}
 
-	if err := g.Wait(); err != nil {
-		return fmt.Errorf("start res watches: %w", err)
+	defer inf.sem.Release(1)
+
+	inf.setResourceSyncState(ctx, res, SyncStateSyncing, nil)
+
+	w, resourceVersion, err := inf.setupWatch(ctx, res)
+	if err != nil {
+		err = fmt.Errorf("setup watch %s: %w", res.String(), err)
+		inf.setResourceSyncState(ctx, res, SyncStateFailed, err)
+
+		return err
+	}
+
+	// the resource was stopped while it was listed.
+	if ctx.Err() != nil {
+		w.Stop()
+		return ctx.Err()
+	}
+
+	if !inf.goroutine(func() {
+		inf.handleWatch(ctx, res, w, resourceVersion)
+	}) {
+		w.Stop()
+		return errInformerStopped
+	}
+
+	if err := inf.setSynced(ctx, res, w); err != nil {
+		return fmt.Errorf("sync watch %s: %w", res.String(), err)
 	}
 
 	return nil
 }
 
-// Stop stops the informer.
+// isPermanentError returns true if err is an API error that retrying
+// will not fix, such as the user not being allowed to list a resource.
+func isPermanentError(err error) bool {
+	var status apierrors.APIStatus
+	if !errors.As(err, &status) {
+		return false
+	}
+
+	switch status.Status().Reason {
+	case metav1.StatusReasonForbidden, metav1.StatusReasonMethodNotAllowed:
+		return true
+	default:
+		return false
+	}
+}
+
+// errInformerStopped is returned when a stopped informer is used.
+var errInformerStopped = errors.New("informer is stopped")
+
+// Stop stops the informer. It stops every watch and event handler the
+// informer created, and waits for its goroutines to finish. A stopped
+// informer can't be started again. Calling Stop more than once is a no-op.
 func (inf *MemoryStoreInformer) Stop() error {
-	inf.mu.Lock()
-	defer inf.mu.Unlock()
+	inf.lifecycleMu.Lock()
+	if inf.stopped {
+		inf.lifecycleMu.Unlock()
+		return nil
+	}
+	inf.stopped = true
+	inf.lifecycleMu.Unlock()
 
 	inf.logger.Info("stopping")
 
-	for k := range inf.synced {
-		delete(inf.synced, k)
+	inf.mu.Lock()
+
+	if inf.cancel != nil {
+		inf.cancel()
+	}
+
+	for k := range inf.syncStates {
+		inf.setSyncState(k, SyncStatePending, nil)
 	}
 
 	for k, w := range inf.apiWatches {
 		w.Stop()
-		delete(inf.synced, k)
+		delete(inf.apiWatches, k)
 	}
 
-	return nil
+	var watchers []*UpdatableWatcher
+	for w := range inf.updatableWatchers {
+		watchers = append(watchers, w)
+	}
+
+	var runners []*eventHandlerRunner
+	for id, runner := range inf.eventHandlers {
+		runners = append(runners, runner)
+		delete(inf.eventHandlers, id)
+	}
+
+	inf.mu.Unlock()
+
+	// watchers unregister themselves when they stop.
+	for _, w := range watchers {
+		w.Stop()
+	}
+
+	for _, runner := range runners {
+		runner.stop()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		inf.goroutines.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-time.After(inf.stopTimeout):
+		return fmt.Errorf("timed out after %s waiting for informer goroutines to stop", inf.stopTimeout)
+	}
+}
+
+// goroutine runs fn in a goroutine that Stop waits for. It returns false
+// without running fn if the informer is stopped.
+func (inf *MemoryStoreInformer) goroutine(fn func()) bool {
+	inf.lifecycleMu.Lock()
+	defer inf.lifecycleMu.Unlock()
+
+	if inf.stopped {
+		return false
+	}
+
+	inf.goroutines.Add(1)
+	go func() {
+		defer inf.goroutines.Done()
+		fn()
+	}()
+
+	return true
+}
+
+func (inf *MemoryStoreInformer) isStopped() bool {
+	inf.lifecycleMu.Lock()
+	defer inf.lifecycleMu.Unlock()
+
+	return inf.stopped
 }
 
 // List list objects from the memory store and falls back to querying the
-// cluster directly if the resource is not synced.
+// cluster directly if the resource is not synced. A paged list continues
+// with the source that issued its continue token.
 func (inf *MemoryStoreInformer) List(
 	ctx context.Context,
 	res schema.GroupVersionResource,
 	options cluster.ListOptions) (*unstructured.UnstructuredList, error) {
+	inf.touch(res)
 
 	inf.mu.RLock()
 	defer inf.mu.RUnlock()
 
 	logger := inf.logger.WithValues("res", res)
 
-	if !inf.isResourceSynced(res) {
+	// a continue token can only be used with the source that issued it.
+	if c := options.Continue; c != "" && !isStoreContinueToken(c) {
+		logger.Info("listing using client")
+		return inf.client.List(ctx, res, options)
+	}
+
+	if (!inf.isResourceSynced(res) || !inf.isNamespaceCached(res, options.Namespace)) && options.Continue == "" {
 		logger.Info("listing using client")
 		return inf.client.List(ctx, res, options)
 	}
@@ -159,13 +476,34 @@ func (inf *MemoryStoreInformer) List(
 	return inf.store.List(res, options)
 }
 
+// Watch watches a resource in the memory store, or in the cluster if the
+// resource is not synced. A cluster watch is switched to the store once the
+// resource is synced.
 func (inf *MemoryStoreInformer) Watch(
 	ctx context.Context,
 	res schema.GroupVersionResource,
 	options cluster.ListOptions) (cluster.Watch, error) {
+	inf.touch(res)
+
 	var w cluster.Watch
 
-	if !inf.isResourceSynced(res) {
+	inf.mu.RLock()
+	cached := inf.isResourceSynced(res) && inf.isNamespaceCached(res, options.Namespace)
+	inf.mu.RUnlock()
+
+	var initial *initialEventsWatch
+
+	switch {
+	case cached:
+	case options.SendInitialEvents:
+		var err error
+		initial, err = inf.watchWithInitialEvents(ctx, res, options)
+		if err != nil {
+			return nil, err
+		}
+
+		w = initial
+	default:
 		clientWatch, err := inf.client.Watch(ctx, res, options)
 		if err != nil {
 			return nil, fmt.Errorf("create watch: %w", err)
@@ -183,17 +521,204 @@ func (inf *MemoryStoreInformer) Watch(
 		w = storeWatcher
 	}
 
+	// the watcher is registered before its stop hook can run.
 	inf.mu.Lock()
-	updatableWatcher := NewUpdatableWatcher(w)
-	inf.watchDescriptors[res] = watchDescriptor{
-		watch:   updatableWatcher,
-		options: options,
+	if inf.isStopped() {
+		inf.mu.Unlock()
+		w.Stop()
+		return nil, errInformerStopped
+	}
+
+	updatableWatcher := newUpdatableWatcher(w, func(uw *UpdatableWatcher) {
+		inf.mu.Lock()
+		defer inf.mu.Unlock()
+
+		inf.activeWatches[res]--
+		delete(inf.watchDescriptors, uw)
+		delete(inf.updatableWatchers, uw)
+	})
+	inf.activeWatches[res]++
+	inf.updatableWatchers[updatableWatcher] = true
+	// a watch of a namespace the store doesn't cache stays on the cluster.
+	if !cached && inf.isNamespaceCached(res, options.Namespace) {
+		inf.watchDescriptors[updatableWatcher] = watchDescriptor{
+			res:     res,
+			options: options,
+			initial: initial,
+		}
 	}
 	inf.mu.Unlock()
 
 	return updatableWatcher, nil
 }
 
+// watchWithInitialEvents watches a resource in the cluster and sends its
+// initial events. A cluster watch doesn't send initial events, so the
+// resource is listed, and the watch starts from the list's resource
+// version.
+func (inf *MemoryStoreInformer) watchWithInitialEvents(
+	ctx context.Context,
+	res schema.GroupVersionResource,
+	options cluster.ListOptions) (*initialEventsWatch, error) {
+	listOptions := options
+	listOptions.SendInitialEvents = false
+	listOptions.ResourceVersion = ""
+	listOptions.Limit = 0
+	listOptions.Continue = ""
+
+	list, err := inf.client.List(ctx, res, listOptions)
+	if err != nil {
+		return nil, fmt.Errorf("list initial objects: %w", err)
+	}
+
+	watchOptions := options
+	watchOptions.SendInitialEvents = false
+	watchOptions.ResourceVersion = list.GetResourceVersion()
+
+	source, err := inf.client.Watch(ctx, res, watchOptions)
+	if err != nil {
+		return nil, fmt.Errorf("create watch: %w", err)
+	}
+
+	var objects []*unstructured.Unstructured
+	for i := range list.Items {
+		objects = append(objects, &list.Items[i])
+	}
+
+	initial := initialEventList(res, objects, list.GetResourceVersion())
+
+	return newInitialEventsWatch(initial, list.GetResourceVersion(), source), nil
+}
+
+// Get gets an object from the memory store and falls back to querying the
+// cluster directly if the resource is not synced. Subresources are always
+// read from the cluster.
+func (inf *MemoryStoreInformer) Get(
+	ctx context.Context,
+	res schema.GroupVersionResource,
+	name string,
+	options cluster.GetOptions) (*unstructured.Unstructured, error) {
+	// the store only has objects, not their subresources.
+	if options.Subresource != "" {
+		return inf.client.Get(ctx, res, name, options)
+	}
+
+	inf.touch(res)
+
+	inf.mu.RLock()
+	defer inf.mu.RUnlock()
+
+	logger := inf.logger.WithValues("res", res)
+
+	if !inf.isResourceSynced(res) || !inf.isNamespaceCached(res, options.Namespace) {
+		logger.Info("getting using client")
+		return inf.client.Get(ctx, res, name, options)
+	}
+
+	logger.Info("getting using store")
+	object, ok := inf.store.Get(res, options.Namespace, name)
+	if !ok {
+		return nil, apierrors.NewNotFound(res.GroupResource(), name)
+	}
+
+	return object, nil
+}
+
+// Create creates an object in the cluster.
+func (inf *MemoryStoreInformer) Create(
+	ctx context.Context,
+	res schema.GroupVersionResource,
+	object *unstructured.Unstructured,
+	options cluster.CreateOptions) (*unstructured.Unstructured, error) {
+	return inf.client.Create(ctx, res, object, options)
+}
+
+// Update updates an object in the cluster.
+func (inf *MemoryStoreInformer) Update(
+	ctx context.Context,
+	res schema.GroupVersionResource,
+	object *unstructured.Unstructured,
+	options cluster.UpdateOptions) (*unstructured.Unstructured, error) {
+	return inf.client.Update(ctx, res, object, options)
+}
+
+// Patch patches an object in the cluster.
+func (inf *MemoryStoreInformer) Patch(
+	ctx context.Context,
+	res schema.GroupVersionResource,
+	name string,
+	patchType types.PatchType,
+	data []byte,
+	options cluster.PatchOptions) (*unstructured.Unstructured, error) {
+	return inf.client.Patch(ctx, res, name, patchType, data, options)
+}
+
+// Apply applies an object in the cluster using server-side apply. If the
+// resource is synced, Apply waits until the applied object is reflected in
+// the memory store, so subsequent reads from the informer see it. The wait
+// ends early if the resource stops being informed.
+func (inf *MemoryStoreInformer) Apply(
+	ctx context.Context,
+	res schema.GroupVersionResource,
+	object *unstructured.Unstructured,
+	options cluster.ApplyOptions) (*unstructured.Unstructured, error) {
+	inf.touch(res)
+
+	inf.mu.RLock()
+	synced := inf.isResourceSynced(res) && inf.isNamespaceCached(res, object.GetNamespace())
+	stopped := inf.resourceDone(res)
+	inf.mu.RUnlock()
+
+	if !synced || options.DryRun {
+		return inf.client.Apply(ctx, res, object, options)
+	}
+
+	// watch the store before applying so the event for the applied object
+	// can't be missed.
+	w, err := inf.store.Watch(res, nameListOptions(object.GetNamespace(), object.GetName()))
+	if err != nil {
+		return nil, fmt.Errorf("create store watcher: %w", err)
+	}
+	defer stopWatch(w)
+
+	applied, err := inf.client.Apply(ctx, res, object, options)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := inf.waitForStore(ctx, stopped, res, w, applied); err != nil {
+		return nil, fmt.Errorf("wait for %s to be stored: %w", applied.GetName(), err)
+	}
+
+	return applied, nil
+}
+
+// Delete deletes an object in the cluster.
+func (inf *MemoryStoreInformer) Delete(
+	ctx context.Context,
+	res schema.GroupVersionResource,
+	name string,
+	options cluster.DeleteOptions) error {
+	return inf.client.Delete(ctx, res, name, options)
+}
+
+// DeleteCollection deletes objects in the cluster.
+func (inf *MemoryStoreInformer) DeleteCollection(
+	ctx context.Context,
+	res schema.GroupVersionResource,
+	options cluster.DeleteOptions,
+	listOptions cluster.ListOptions) error {
+	return inf.client.DeleteCollection(ctx, res, options, listOptions)
+}
+
+// Evict evicts a pod in the cluster.
+func (inf *MemoryStoreInformer) Evict(
+	ctx context.Context,
+	name string,
+	options cluster.DeleteOptions) error {
+	return inf.client.Evict(ctx, name, options)
+}
+
 func (inf *MemoryStoreInformer) Resources() (cluster.Resources, error) {
 	return inf.client.Resources()
 }
@@ -202,54 +727,391 @@ func (inf *MemoryStoreInformer) isResourceSynced(res schema.GroupVersionResource
 	return inf.synced[res]
 }
 
+// SetSynced marks a resource as synced. apiWatch is the cluster watch
+// keeping the store current.
 func (inf *MemoryStoreInformer) SetSynced(res schema.GroupVersionResource, apiWatch cluster.Watch) error {
+	return inf.setSynced(context.Background(), res, apiWatch)
+}
+
+// setSynced marks a resource as synced unless ctx, the context it is
+// informed with, is done. ctx is checked under the lock stopResource holds
+// while canceling it, so a stopped resource is never marked as synced.
+func (inf *MemoryStoreInformer) setSynced(ctx context.Context, res schema.GroupVersionResource, apiWatch cluster.Watch) error {
 	inf.mu.Lock()
 	defer inf.mu.Unlock()
 
-	inf.synced[res] = true
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	inf.setSyncState(res, SyncStateSynced, nil)
 	inf.apiWatches[res] = apiWatch
 
-	wd, ok := inf.watchDescriptors[res]
-	if ok {
-		// there was a watch created before the resource was synced
+	// switch watches created before the resource was synced to the store.
+	// The store watch starts after the last event the caller received.
+	for uw, wd := range inf.watchDescriptors {
+		if wd.res != res || !inf.isNamespaceCached(res, wd.options.Namespace) {
+			continue
+		}
 
-		// create a new watch from the store.
-		storeWatcher, err := inf.store.Watch(res, wd.options)
-		if err != nil {
-			return fmt.Errorf("create store watcher: %w", err)
+		// the cluster watch sends the initial events, so the store watch
+		// doesn't.
+		options := wd.options
+		options.SendInitialEvents = false
+		if rv := uw.ResourceVersion(); rv != "" {
+			options.ResourceVersion = rv
+		}
+
+		if wd.initial != nil {
+			// the initial events aren't in the store's history, so the
+			// cluster watch is kept until they have been sent.
+			rv, sent := wd.initial.initialEventsSent()
+			if !sent {
+				continue
+			}
+
+			if !isResourceVersionNewer(options.ResourceVersion, rv) {
+				options.ResourceVersion = rv
+			}
 		}
 
-		delete(inf.watchDescriptors, res)
+		storeWatcher, err := inf.store.Watch(res, options)
+		if err != nil {
+			// the cluster watch is kept.
+			inf.logger.Error(err, "create store watcher", "res", res)
+			continue
+		}
 
-		// set the existing watch
-		wd.watch.SetSource(storeWatcher)
+		delete(inf.watchDescriptors, uw)
+		uw.SetSource(storeWatcher)
 	}
 
 	return nil
 }
 
+// setResourceSyncState sets the sync state of a resource unless ctx, the
+// context it is informed with, is done, so a stopped resource's state is
+// not brought back.
+func (inf *MemoryStoreInformer) setResourceSyncState(
+	ctx context.Context,
+	res schema.GroupVersionResource,
+	state SyncState,
+	err error) {
+	inf.mu.Lock()
+	defer inf.mu.Unlock()
+
+	if ctx.Err() != nil {
+		return
+	}
+
+	inf.setSyncState(res, state, err)
+}
+
+// setUnsynced marks a resource as syncing, so reads fall back to the
+// cluster. A resource whose context is done is left alone.
+func (inf *MemoryStoreInformer) setUnsynced(ctx context.Context, res schema.GroupVersionResource) {
+	inf.mu.Lock()
+	defer inf.mu.Unlock()
+
+	if ctx.Err() != nil {
+		return
+	}
+
+	inf.setSyncState(res, SyncStateSyncing, nil)
+	delete(inf.apiWatches, res)
+}
+
+// setupWatch lists a resource, reconciles the store with the list, and
+// watches the resource from the list's resource version. When the informer
+// is limited to namespaces, each namespace is listed and watched, and the
+// watches are merged.
 func (inf *MemoryStoreInformer) setupWatch(
 	ctx context.Context,
-	res schema.GroupVersionResource) (cluster.Watch, error) {
-	list, err := inf.client.List(ctx, res, cluster.ListOptions{})
-	if err != nil {
-		return nil, fmt.Errorf("list: %w", err)
+	res schema.GroupVersionResource) (cluster.Watch, string, error) {
+	scopes := inf.scopes(res)
+
+	merged := &unstructured.UnstructuredList{}
+	versions := map[string]string{}
+
+	for _, namespace := range scopes {
+		list, err := inf.client.List(ctx, res, cluster.ListOptions{Namespace: namespace})
+		if err != nil {
+			if namespace != "" {
+				return nil, "", fmt.Errorf("list namespace %s: %w", namespace, err)
+			}
+			return nil, "", fmt.Errorf("list: %w", err)
+		}
+
+		merged.Items = append(merged.Items, list.Items...)
+		versions[namespace] = list.GetResourceVersion()
+
+		// the merged list is as new as its newest namespace list.
+		if rv := list.GetResourceVersion(); !isResourceVersionNewer(merged.GetResourceVersion(), rv) {
+			merged.SetResourceVersion(rv)
+		}
 	}
 
-	for _, object := range list.Items {
-		inf.store.Update(res, &object)
+	if err := inf.reconcile(res, merged); err != nil {
+		return nil, "", fmt.Errorf("reconcile store: %w", err)
 	}
 
-	w, err := inf.client.Watch(ctx, res, cluster.ListOptions{})
+	resume := func(namespace, resourceVersion string) (cluster.Watch, error) {
+		return inf.client.Watch(ctx, res, cluster.ListOptions{
+			ListOptions: metav1.ListOptions{ResourceVersion: resourceVersion},
+			Namespace:   namespace,
+		})
+	}
+
+	watches := map[string]cluster.Watch{}
+
+	for namespace, resourceVersion := range versions {
+		w, err := resume(namespace, resourceVersion)
+		if err != nil {
+			for _, w := range watches {
+				w.Stop()
+			}
+
+			if namespace != "" {
+				return nil, "", fmt.Errorf("watch namespace %s: %w", namespace, err)
+			}
+			return nil, "", fmt.Errorf("watch: %w", err)
+		}
+
+		watches[namespace] = w
+	}
+
+	if len(scopes) == 1 {
+		return watches[scopes[0]], versions[scopes[0]], nil
+	}
+
+	return newNamespaceWatch(watches, versions, resume), "", nil
+}
+
+// reconcile makes the store match a list from the cluster. Objects in the
+// store that are not in the list are deleted at the list's resource
+// version, and objects whose resource version is unchanged are left alone.
+func (inf *MemoryStoreInformer) reconcile(res schema.GroupVersionResource, list *unstructured.UnstructuredList) error {
+	current, err := inf.store.List(res, cluster.ListOptions{})
 	if err != nil {
-		return nil, fmt.Errorf("watch: %w", err)
+		return fmt.Errorf("list store: %w", err)
+	}
+
+	currentVersions := map[storeKey]string{}
+	for i := range current.Items {
+		currentVersions[storeKeyFor(&current.Items[i])] = current.Items[i].GetResourceVersion()
+	}
+
+	listed := map[storeKey]bool{}
+	for i := range list.Items {
+		object := &list.Items[i]
+		key := storeKeyFor(object)
+		listed[key] = true
+
+		if rv, ok := currentVersions[key]; ok && rv != "" && rv == object.GetResourceVersion() {
+			continue
+		}
+
+		inf.store.Update(res, object)
+	}
+
+	for i := range current.Items {
+		if listed[storeKeyFor(&current.Items[i])] {
+			continue
+		}
+
+		// the delete happened before the list, so it is recorded at the
+		// list's resource version for watches resuming after the relist.
+		object := current.Items[i].DeepCopy()
+		if rv := list.GetResourceVersion(); rv != "" {
+			object.SetResourceVersion(rv)
+		}
+
+		inf.store.Delete(res, object)
 	}
 
-	return w, nil
+	return nil
 }
 
-func (inf *MemoryStoreInformer) handleWatch(res schema.GroupVersionResource, w cluster.Watch) {
-	for event := range w.ResultChan() {
+// relist lists and watches a resource again, retrying with backoff until it
+// succeeds or ctx is canceled. The resource is not synced while relisting.
+func (inf *MemoryStoreInformer) relist(ctx context.Context, res schema.GroupVersionResource) (cluster.Watch, string, error) {
+	inf.setUnsynced(ctx, res)
+
+	backoff := inf.backoff
+
+	for {
+		w, resourceVersion, err := inf.setupWatch(ctx, res)
+		if err == nil && ctx.Err() != nil {
+			w.Stop()
+			return nil, "", ctx.Err()
+		}
+
+		if err == nil {
+			if err := inf.setSynced(ctx, res, w); err != nil {
+				w.Stop()
+				return nil, "", err
+			}
+
+			return w, resourceVersion, nil
+		}
+
+		inf.logger.Error(err, "relist failed", "res", res)
+
+		select {
+		case <-ctx.Done():
+			return nil, "", ctx.Err()
+		case <-time.After(backoff.Step()):
+		}
+	}
+}
+
+// waitForStore waits until the store contains object at its resource
+// version or newer. It stops waiting without an error when stopped is
+// closed or the store watch ends, since the store no longer follows the
+// resource.
+func (inf *MemoryStoreInformer) waitForStore(
+	ctx context.Context,
+	stopped <-chan struct{},
+	res schema.GroupVersionResource,
+	w cluster.Watch,
+	object *unstructured.Unstructured) error {
+	isCurrent := func(u *unstructured.Unstructured) bool {
+		return u.GetNamespace() == object.GetNamespace() &&
+			u.GetName() == object.GetName() &&
+			isResourceVersionAtLeast(u.GetResourceVersion(), object.GetResourceVersion())
+	}
+
+	// an apply that changes nothing will not generate an event.
+	if u, ok := inf.store.Get(res, object.GetNamespace(), object.GetName()); ok && isCurrent(u) {
+		return nil
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-stopped:
+			return nil
+		case e, ok := <-w.ResultChan():
+			if !ok {
+				return nil
+			}
+
+			if u, ok := e.Object.(*unstructured.Unstructured); ok && isCurrent(u) {
+				return nil
+			}
+		}
+	}
+}
+
+// nameListOptions creates list options that select a single object.
+func nameListOptions(namespace, name string) cluster.ListOptions {
+	return cluster.ListOptions{
+		ListOptions: metav1.ListOptions{
+			FieldSelector: fields.OneTermEqualSelector("metadata.name", name).String(),
+		},
+		Namespace: namespace,
+	}
+}
+
+// stopWatch stops a watch and drains its result channel, so a sender
+// blocked on it can observe the stop.
+func stopWatch(w cluster.Watch) {
+	go func() {
+		for range w.ResultChan() {
+		}
+	}()
+
+	w.Stop()
+}
+
+// handleWatch applies events from an API watch to the store until ctx is
+// canceled. When the watch ends, it is resumed from the last seen resource
+// version. When that is not possible, or the watch reports an error such as
+// an expired resource version, the resource is relisted.
+func (inf *MemoryStoreInformer) handleWatch(
+	ctx context.Context,
+	res schema.GroupVersionResource,
+	w cluster.Watch,
+	resourceVersion string) {
+	logger := inf.logger.WithValues("res", res)
+
+	for {
+		var watchErr error
+		resourceVersion, watchErr = inf.processWatch(ctx, res, w, resourceVersion)
+		w.Stop()
+
+		if ctx.Err() != nil {
+			break
+		}
+
+		// a merged namespace watch resumes its own watches, so it only ends
+		// cleanly when it is stopped.
+		scopes := inf.scopes(res)
+		if watchErr == nil && len(scopes) > 1 {
+			watchErr = fmt.Errorf("namespace watch ended")
+		}
+
+		if watchErr == nil {
+			logger.Info("resuming watch", "resourceVersion", resourceVersion)
+
+			next, err := inf.client.Watch(ctx, res, cluster.ListOptions{
+				ListOptions: metav1.ListOptions{ResourceVersion: resourceVersion},
+				Namespace:   scopes[0],
+			})
+			if err == nil {
+				w = next
+				continue
+			}
+
+			watchErr = err
+		}
+
+		logger.Info("relisting", "reason", watchErr.Error())
+
+		next, nextResourceVersion, err := inf.relist(ctx, res)
+		if err != nil {
+			break
+		}
+
+		w, resourceVersion = next, nextResourceVersion
+	}
+
+	logger.Info("watch is ending")
+}
+
+// processWatch applies events from a watch to the store until the watch
+// ends, reports an error, or ctx is done. It returns the last seen resource
+// version.
+func (inf *MemoryStoreInformer) processWatch(
+	ctx context.Context,
+	res schema.GroupVersionResource,
+	w cluster.Watch,
+	resourceVersion string) (string, error) {
+	for {
+		var event watch.Event
+		var ok bool
+
+		select {
+		case <-ctx.Done():
+			return resourceVersion, nil
+		case event, ok = <-w.ResultChan():
+		}
+
+		if !ok {
+			return resourceVersion, nil
+		}
+
+		if event.Type == watch.Error {
+			return resourceVersion, apierrors.FromObject(event.Object)
+		}
+
+		if accessor, err := meta.Accessor(event.Object); err == nil {
+			if rv := accessor.GetResourceVersion(); rv != "" {
+				resourceVersion = rv
+			}
+		}
+
 		switch event.Type {
 		case watch.Added:
 			inf.store.Add(res, event.Object)
@@ -257,12 +1119,11 @@ func (inf *MemoryStoreInformer) handleWatch(res schema.GroupVersionResource, w c
 			inf.store.Update(res, event.Object)
 		case watch.Deleted:
 			inf.store.Delete(res, event.Object)
+		case watch.Bookmark:
 		default:
 			inf.logger.Info("unknown watch event type",
 				"event-type", event.Type,
 				"event", event.Object)
 		}
 	}
-
-	inf.logger.Info("watch is ending", "res", res)
 }
@@ -4,12 +4,18 @@ import (
 	"context"
 	"log"
 	"os"
+	goruntime "runtime"
 	"testing"
+	"time"
 
 	"github.com/go-logr/stdr"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
 
 	"github.com/bryanl/clientkube/pkg/cluster"
 	"github.com/bryanl/clientkube/pkg/mocks"
@@ -41,6 +47,8 @@ func TestMemoryStoreInformer_Watch(t *testing.T) {
 			},
 			initClient: func(ctrl *gomock.Controller, options cluster.ListOptions) cluster.Client {
 				w := mocks.NewMockWatch(ctrl)
+				w.EXPECT().ResultChan().Return(make(chan watch.Event)).AnyTimes()
+				w.EXPECT().Stop()
 				client := mocks.NewMockClient(ctrl)
 				client.EXPECT().Watch(gomock.Any(), res, options).Return(w, nil)
 
@@ -51,9 +59,13 @@ func TestMemoryStoreInformer_Watch(t *testing.T) {
 		{
 			name: "watch for synced resource builds watch from store",
 			options: func(ctrl *gomock.Controller, options cluster.ListOptions) []Option {
+				w := mocks.NewMockWatch(ctrl)
+				w.EXPECT().ResultChan().Return(make(chan watch.Event)).AnyTimes()
+				w.EXPECT().Stop()
 				s := mocks.NewMockStore(ctrl)
 				s.EXPECT().
-					Watch(res, options)
+					Watch(res, options).
+					Return(w, nil)
 
 				return []Option{
 					loggerOption,
@@ -83,9 +95,606 @@ func TestMemoryStoreInformer_Watch(t *testing.T) {
 				fn(msi)
 			}
 
-			_, err := msi.Watch(ctx, res, test.listOptions)
+			w, err := msi.Watch(ctx, res, test.listOptions)
+			require.NoError(t, err)
+
+			w.Stop()
+			_, ok := <-w.ResultChan()
+			require.False(t, ok)
+
+			ctrl.Finish()
+		})
+	}
+}
+
+func TestMemoryStoreInformer_Get(t *testing.T) {
+	res := schema.GroupVersionResource{
+		Group:    "group",
+		Version:  "version",
+		Resource: "resource",
+	}
+
+	object := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": res.GroupVersion().String(),
+			"kind":       "Resource",
+			"metadata": map[string]interface{}{
+				"name":      "object",
+				"namespace": "default",
+			},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		objectName string
+		options    cluster.GetOptions
+		synced     bool
+		initClient func(ctrl *gomock.Controller) cluster.Client
+		wanted     *unstructured.Unstructured
+		wantErr    bool
+	}{
+		{
+			name:       "get for unsynced resource uses client",
+			objectName: object.GetName(),
+			options:    cluster.GetOptions{Namespace: object.GetNamespace()},
+			initClient: func(ctrl *gomock.Controller) cluster.Client {
+				client := mocks.NewMockClient(ctrl)
+				client.EXPECT().
+					Get(gomock.Any(), res, object.GetName(), cluster.GetOptions{Namespace: object.GetNamespace()}).
+					Return(object, nil)
+				return client
+			},
+			wanted: object,
+		},
+		{
+			name:       "get for synced resource uses store",
+			objectName: object.GetName(),
+			options:    cluster.GetOptions{Namespace: object.GetNamespace()},
+			synced:     true,
+			initClient: func(ctrl *gomock.Controller) cluster.Client {
+				return mocks.NewMockClient(ctrl)
+			},
+			wanted: object,
+		},
+		{
+			name:       "get subresource for synced resource uses client",
+			objectName: object.GetName(),
+			options:    cluster.GetOptions{Namespace: object.GetNamespace(), Subresource: "status"},
+			synced:     true,
+			initClient: func(ctrl *gomock.Controller) cluster.Client {
+				client := mocks.NewMockClient(ctrl)
+				client.EXPECT().
+					Get(gomock.Any(), res, object.GetName(), cluster.GetOptions{
+						Namespace:   object.GetNamespace(),
+						Subresource: "status",
+					}).
+					Return(object, nil)
+				return client
+			},
+			wanted: object,
+		},
+		{
+			name:       "get for synced resource that does not exist",
+			objectName: "missing",
+			options:    cluster.GetOptions{Namespace: object.GetNamespace()},
+			synced:     true,
+			initClient: func(ctrl *gomock.Controller) cluster.Client {
+				return mocks.NewMockClient(ctrl)
+			},
+			wantErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.Background()
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := NewMemoryStore()
+			store.Update(res, object)
+
+			msi := NewInformer(test.initClient(ctrl), WithStore(store))
+			if test.synced {
+				require.NoError(t, msi.SetSynced(res, nil))
+			}
+
+			actual, err := msi.Get(ctx, res, test.objectName, test.options)
+			if test.wantErr {
+				require.True(t, apierrors.IsNotFound(err))
+				return
+			}
+			require.NoError(t, err)
+
+			require.Equal(t, test.wanted, actual)
+		})
+	}
+}
+
+func TestMemoryStoreInformer_Apply(t *testing.T) {
+	res := schema.GroupVersionResource{
+		Group:    "group",
+		Version:  "version",
+		Resource: "resource",
+	}
+
+	object := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": res.GroupVersion().String(),
+			"kind":       "Resource",
+			"metadata": map[string]interface{}{
+				"name":      "object",
+				"namespace": "default",
+			},
+		},
+	}
+
+	applied := object.DeepCopy()
+	applied.SetResourceVersion("2")
+
+	applyOptions := cluster.ApplyOptions{FieldManager: "test"}
+
+	tests := []struct {
+		name       string
+		synced     bool
+		initClient func(ctrl *gomock.Controller, store *MemoryStore) cluster.Client
+	}{
+		{
+			name: "apply for unsynced resource uses client",
+			initClient: func(ctrl *gomock.Controller, store *MemoryStore) cluster.Client {
+				client := mocks.NewMockClient(ctrl)
+				client.EXPECT().
+					Apply(gomock.Any(), res, object, applyOptions).
+					Return(applied, nil)
+				return client
+			},
+		},
+		{
+			name:   "apply for synced resource waits for store",
+			synced: true,
+			initClient: func(ctrl *gomock.Controller, store *MemoryStore) cluster.Client {
+				client := mocks.NewMockClient(ctrl)
+				client.EXPECT().
+					Apply(gomock.Any(), res, object, applyOptions).
+					DoAndReturn(func(context.Context, schema.GroupVersionResource, *unstructured.Unstructured, cluster.ApplyOptions) (*unstructured.Unstructured, error) {
+						go store.Update(res, applied.DeepCopy())
+						return applied, nil
+					})
+				return client
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := NewMemoryStore()
+
+			msi := NewInformer(test.initClient(ctrl, store), WithStore(store))
+			if test.synced {
+				require.NoError(t, msi.SetSynced(res, nil))
+			}
+
+			actual, err := msi.Apply(ctx, res, object, applyOptions)
+			require.NoError(t, err)
+			require.Equal(t, applied, actual)
+
+			if test.synced {
+				got, err := msi.Get(ctx, res, object.GetName(), cluster.GetOptions{Namespace: object.GetNamespace()})
+				require.NoError(t, err)
+				require.Equal(t, applied, got)
+			}
+		})
+	}
+}
+
+func TestMemoryStoreInformer_Apply_resourceStopped(t *testing.T) {
+	res := schema.GroupVersionResource{
+		Group:    "group",
+		Version:  "version",
+		Resource: "resource",
+	}
+
+	object := &unstructured.Unstructured{}
+	object.SetAPIVersion(res.GroupVersion().String())
+	object.SetKind("Resource")
+	object.SetNamespace("default")
+	object.SetName("object")
+
+	applied := object.DeepCopy()
+	applied.SetResourceVersion("2")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	client := mocks.NewMockClient(ctrl)
+
+	msi := NewInformer(client, WithStore(NewMemoryStore()))
+	msi.ctx = context.Background()
+
+	resourceCtx := msi.resourceContext(res)
+	require.NoError(t, msi.setSynced(resourceCtx, res, watch.NewFake()))
+
+	// the resource is stopped before the applied object reaches the store.
+	client.EXPECT().
+		Apply(gomock.Any(), res, object, cluster.ApplyOptions{}).
+		DoAndReturn(func(context.Context, schema.GroupVersionResource, *unstructured.Unstructured, cluster.ApplyOptions) (*unstructured.Unstructured, error) {
+			go msi.stopResource(res)
+			return applied, nil
+		})
+
+	actual, err := msi.Apply(ctx, res, object, cluster.ApplyOptions{})
+	require.NoError(t, err)
+	require.Equal(t, applied, actual)
+	require.NoError(t, ctx.Err())
+}
+
+func TestMemoryStoreInformer_List(t *testing.T) {
+	res := schema.GroupVersionResource{
+		Group:    "group",
+		Version:  "version",
+		Resource: "resource",
+	}
+
+	storeToken, err := encodeContinueToken(res, storeKey{namespace: "default", name: "object"})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		synced      bool
+		listOptions cluster.ListOptions
+		useClient   bool
+	}{
+		{
+			name:      "list for unsynced resource uses client",
+			useClient: true,
+		},
+		{
+			name:   "list for synced resource uses store",
+			synced: true,
+		},
+		{
+			name:        "list with client continue token uses client",
+			synced:      true,
+			listOptions: cluster.ListOptions{ListOptions: metav1.ListOptions{Continue: "client-token"}},
+			useClient:   true,
+		},
+		{
+			name:        "list with store continue token uses store",
+			listOptions: cluster.ListOptions{ListOptions: metav1.ListOptions{Continue: storeToken}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.Background()
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			client := mocks.NewMockClient(ctrl)
+			store := mocks.NewMockStore(ctrl)
+
+			if test.useClient {
+				client.EXPECT().
+					List(gomock.Any(), res, test.listOptions).
+					Return(&unstructured.UnstructuredList{}, nil)
+			} else {
+				store.EXPECT().
+					List(res, test.listOptions).
+					Return(&unstructured.UnstructuredList{}, nil)
+			}
+
+			msi := NewInformer(client, WithStore(store))
+			if test.synced {
+				require.NoError(t, msi.SetSynced(res, nil))
+			}
 
+			_, err := msi.List(ctx, res, test.listOptions)
 			require.NoError(t, err)
 		})
 	}
 }
+
+func TestMemoryStoreInformer_relist(t *testing.T) {
+	res := schema.GroupVersionResource{
+		Group:    "group",
+		Version:  "version",
+		Resource: "resource",
+	}
+
+	newObject := func(name, resourceVersion string) unstructured.Unstructured {
+		return unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"apiVersion": res.GroupVersion().String(),
+				"kind":       "Resource",
+				"metadata": map[string]interface{}{
+					"name":            name,
+					"namespace":       "default",
+					"resourceVersion": resourceVersion,
+				},
+			},
+		}
+	}
+
+	newList := func(resourceVersion string, items ...unstructured.Unstructured) *unstructured.UnstructuredList {
+		list := &unstructured.UnstructuredList{Items: items}
+		list.SetResourceVersion(resourceVersion)
+		return list
+	}
+
+	watchOptions := func(resourceVersion string) cluster.ListOptions {
+		return cluster.ListOptions{ListOptions: metav1.ListOptions{ResourceVersion: resourceVersion}}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	newWatch := func() (*mocks.MockWatch, chan watch.Event) {
+		ch := make(chan watch.Event)
+		w := mocks.NewMockWatch(ctrl)
+		w.EXPECT().ResultChan().Return(ch).AnyTimes()
+		w.EXPECT().Stop().AnyTimes()
+		return w, ch
+	}
+
+	w1, ch1 := newWatch()
+	w2, ch2 := newWatch()
+	w3, _ := newWatch()
+
+	resumed := make(chan struct{})
+
+	client := mocks.NewMockClient(ctrl)
+	client.EXPECT().Resources().Return(cluster.Resources{
+		newResource(res.GroupVersion(), metav1.APIResource{
+			Name:       res.Resource,
+			Namespaced: true,
+			Kind:       "Resource",
+			Verbs:      metav1.Verbs{"list", "watch"},
+		}),
+	}, nil)
+
+	gomock.InOrder(
+		client.EXPECT().
+			List(gomock.Any(), res, cluster.ListOptions{}).
+			Return(newList("10", newObject("a", "5"), newObject("b", "6")), nil),
+		client.EXPECT().Watch(gomock.Any(), res, watchOptions("10")).Return(w1, nil),
+		client.EXPECT().
+			List(gomock.Any(), res, cluster.ListOptions{}).
+			Return(newList("20", newObject("b", "15"), newObject("c", "16")), nil),
+		client.EXPECT().Watch(gomock.Any(), res, watchOptions("20")).Return(w2, nil),
+		client.EXPECT().
+			Watch(gomock.Any(), res, watchOptions("21")).
+			DoAndReturn(func(context.Context, schema.GroupVersionResource, cluster.ListOptions) (cluster.Watch, error) {
+				close(resumed)
+				return w3, nil
+			}),
+	)
+
+	store := NewMemoryStore()
+	msi := NewInformer(client, WithStore(store))
+	require.NoError(t, msi.Start(ctx))
+	defer func() {
+		require.NoError(t, msi.Stop())
+	}()
+
+	names := func() []string {
+		list, err := store.List(res, cluster.ListOptions{})
+		require.NoError(t, err)
+
+		var got []string
+		for _, item := range list.Items {
+			got = append(got, item.GetName()+"@"+item.GetResourceVersion())
+		}
+
+		return got
+	}
+
+	require.Equal(t, []string{"a@5", "b@6"}, names())
+
+	// an expired watch triggers a relist, which deletes objects that are gone.
+	ch1 <- watch.Event{
+		Type:   watch.Error,
+		Object: &apierrors.NewResourceExpired("too old resource version").ErrStatus,
+	}
+
+	require.Eventually(t, func() bool {
+		msi.mu.RLock()
+		defer msi.mu.RUnlock()
+		return msi.isResourceSynced(res) && len(names()) == 2 && names()[0] == "b@15"
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Equal(t, []string{"b@15", "c@16"}, names())
+
+	// a closed watch is resumed from the last seen resource version.
+	object := newObject("c", "21")
+	ch2 <- watch.Event{Type: watch.Modified, Object: &object}
+	close(ch2)
+
+	select {
+	case <-resumed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("watch was not resumed")
+	}
+	require.Equal(t, []string{"b@15", "c@21"}, names())
+}
+
+func TestMemoryStoreInformer_reconcile_resumeAfterRelist(t *testing.T) {
+	res := schema.GroupVersionResource{
+		Group:    "group",
+		Version:  "version",
+		Resource: "resource",
+	}
+
+	store := NewMemoryStore()
+	store.Add(res, newVersionedObject("a", "5"))
+	store.Add(res, newVersionedObject("b", "6"))
+
+	msi := NewInformer(nil, WithStore(store))
+
+	// a is deleted and b is modified while the informer is not watching.
+	list := &unstructured.UnstructuredList{
+		Items: []unstructured.Unstructured{*newVersionedObject("b", "15")},
+	}
+	list.SetResourceVersion("20")
+	require.NoError(t, msi.reconcile(res, list))
+
+	// a watch resuming after the events it saw learns about the delete.
+	w, err := store.Watch(res, cluster.ListOptions{
+		ListOptions: metav1.ListOptions{ResourceVersion: "10"},
+	})
+	require.NoError(t, err)
+	defer w.Stop()
+
+	e := receiveEvent(t, w)
+	require.Equal(t, watch.Modified, e.Type)
+	require.Equal(t, "b", e.Object.(*unstructured.Unstructured).GetName())
+
+	e = receiveEvent(t, w)
+	require.Equal(t, watch.Deleted, e.Type)
+	require.Equal(t, "a", e.Object.(*unstructured.Unstructured).GetName())
+	require.Equal(t, "20", e.Object.(*unstructured.Unstructured).GetResourceVersion())
+}
+
+func TestMemoryStoreInformer_Stop(t *testing.T) {
+	res := schema.GroupVersionResource{
+		Group:    "group",
+		Version:  "version",
+		Resource: "resource",
+	}
+
+	before := goruntime.NumGoroutine()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	apiWatch := mocks.NewMockWatch(ctrl)
+	apiWatch.EXPECT().ResultChan().Return(make(chan watch.Event)).AnyTimes()
+	apiWatch.EXPECT().Stop().MinTimes(1)
+
+	client := mocks.NewMockClient(ctrl)
+	client.EXPECT().Resources().Return(cluster.Resources{
+		newResource(res.GroupVersion(), metav1.APIResource{
+			Name:       res.Resource,
+			Namespaced: true,
+			Kind:       "Resource",
+			Verbs:      metav1.Verbs{"list", "watch"},
+		}),
+	}, nil)
+	client.EXPECT().List(gomock.Any(), res, cluster.ListOptions{}).Return(&unstructured.UnstructuredList{}, nil)
+	client.EXPECT().Watch(gomock.Any(), res, gomock.Any()).Return(apiWatch, nil)
+
+	msi := NewInformer(client, WithStopTimeout(5*time.Second))
+	require.NoError(t, msi.Start(context.Background()))
+
+	w, err := msi.Watch(context.Background(), res, cluster.ListOptions{})
+	require.NoError(t, err)
+
+	_, err = msi.AddEventHandler(res, ResourceEventHandler{})
+	require.NoError(t, err)
+
+	require.NoError(t, msi.Stop())
+	require.NoError(t, msi.Stop())
+
+	// watches returned by the informer are stopped.
+	_, ok := <-w.ResultChan()
+	require.False(t, ok)
+
+	// the informer can't be used again.
+	require.Error(t, msi.Start(context.Background()))
+	_, err = msi.AddEventHandler(res, ResourceEventHandler{})
+	require.Error(t, err)
+
+	// require.Eventually runs its condition in a goroutine, so the
+	// goroutines are counted here.
+	deadline := time.Now().Add(5 * time.Second)
+	for goruntime.NumGoroutine() > before && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	require.LessOrEqual(t, goruntime.NumGoroutine(), before)
+}
+
+func TestMemoryStoreInformer_Watch_initialEventsFromClient(t *testing.T) {
+	res := schema.GroupVersionResource{
+		Group:    "group",
+		Version:  "version",
+		Resource: "resource",
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	list := &unstructured.UnstructuredList{
+		Items: []unstructured.Unstructured{
+			*newVersionedObject("a", "8"),
+			*newVersionedObject("b", "9"),
+		},
+	}
+	list.SetResourceVersion("10")
+
+	source := watch.NewFakeWithChanSize(10, false)
+
+	client := mocks.NewMockClient(ctrl)
+	client.EXPECT().
+		List(gomock.Any(), res, cluster.ListOptions{Namespace: "default"}).
+		Return(list, nil)
+	client.EXPECT().
+		Watch(gomock.Any(), res, cluster.ListOptions{
+			ListOptions: metav1.ListOptions{ResourceVersion: "10"},
+			Namespace:   "default",
+		}).
+		Return(source, nil)
+
+	store := NewMemoryStore()
+	msi := NewInformer(client, WithStore(store))
+
+	w, err := msi.Watch(context.Background(), res, cluster.ListOptions{
+		ListOptions:       metav1.ListOptions{ResourceVersion: "3"},
+		Namespace:         "default",
+		SendInitialEvents: true,
+	})
+	require.NoError(t, err)
+
+	e := receiveEvent(t, w)
+	require.Equal(t, watch.Added, e.Type)
+	require.Equal(t, "a", e.Object.(*unstructured.Unstructured).GetName())
+
+	e = receiveEvent(t, w)
+	require.Equal(t, watch.Added, e.Type)
+	require.Equal(t, "b", e.Object.(*unstructured.Unstructured).GetName())
+
+	e = receiveEvent(t, w)
+	require.Equal(t, watch.Bookmark, e.Type)
+	bookmark := e.Object.(*unstructured.Unstructured)
+	require.Equal(t, "10", bookmark.GetResourceVersion())
+	require.Equal(t, "true", bookmark.GetAnnotations()[cluster.InitialEventsEndAnnotation])
+
+	source.Modify(newVersionedObject("a", "11"))
+	e = receiveEvent(t, w)
+	require.Equal(t, watch.Modified, e.Type)
+	require.Equal(t, "11", e.Object.(*unstructured.Unstructured).GetResourceVersion())
+
+	// the store catches up with the cluster, and the watch is switched to
+	// it without repeating the initial events.
+	store.Add(res, newVersionedObject("b", "9"))
+	store.Add(res, newVersionedObject("a", "11"))
+	store.Update(res, newVersionedObject("a", "12"))
+	require.NoError(t, msi.SetSynced(res, nil))
+
+	require.Eventually(t, source.IsStopped, time.Second, 10*time.Millisecond)
+
+	e = receiveEvent(t, w)
+	require.Equal(t, watch.Modified, e.Type)
+	require.Equal(t, "12", e.Object.(*unstructured.Unstructured).GetResourceVersion())
+
+	w.Stop()
+}
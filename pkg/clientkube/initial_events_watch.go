@@ -0,0 +1,123 @@
+package clientkube
+
+import (
+	"sync"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+
+	"github.com/bryanl/clientkube/pkg/cluster"
+)
+
+// initialEventsWatch sends initial events, then forwards the events of a
+// source watch. It sends initial events for a cluster watch, which only
+// sends events after the resource version it was started from.
+type initialEventsWatch struct {
+	ch     chan watch.Event
+	done   chan struct{}
+	once   sync.Once
+	source cluster.Watch
+
+	// resourceVersion is the version the initial events were read at.
+	resourceVersion string
+	sent            bool
+	mu              sync.Mutex
+}
+
+var _ cluster.Watch = &initialEventsWatch{}
+
+// newInitialEventsWatch sends initial before the events from source.
+// source must start after resourceVersion.
+func newInitialEventsWatch(initial []watch.Event, resourceVersion string, source cluster.Watch) *initialEventsWatch {
+	w := initialEventsWatch{
+		ch:              make(chan watch.Event),
+		done:            make(chan struct{}),
+		source:          source,
+		resourceVersion: resourceVersion,
+	}
+
+	go w.run(initial)
+
+	return &w
+}
+
+// Stop stops the watch and its source. It can be called more than once.
+func (w *initialEventsWatch) Stop() {
+	w.once.Do(func() {
+		close(w.done)
+		stopWatch(w.source)
+	})
+}
+
+// ResultChan returns the initial events followed by the source's events.
+func (w *initialEventsWatch) ResultChan() <-chan watch.Event {
+	return w.ch
+}
+
+// initialEventsSent returns the resource version the initial events were
+// read at, and whether all of them have been sent.
+func (w *initialEventsWatch) initialEventsSent() (string, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.resourceVersion, w.sent
+}
+
+func (w *initialEventsWatch) run(initial []watch.Event) {
+	defer close(w.ch)
+
+	send := func(e watch.Event) bool {
+		select {
+		case <-w.done:
+			return false
+		case w.ch <- e:
+			return true
+		}
+	}
+
+	for _, e := range initial {
+		if !send(e) {
+			return
+		}
+	}
+
+	w.mu.Lock()
+	w.sent = true
+	w.mu.Unlock()
+
+	for {
+		select {
+		case <-w.done:
+			return
+		case e, ok := <-w.source.ResultChan():
+			if !ok {
+				return
+			}
+
+			if !send(e) {
+				return
+			}
+		}
+	}
+}
+
+// initialEventList returns an added event for each object, followed by a
+// bookmark at resourceVersion marking the end of the initial events.
+func initialEventList(
+	res schema.GroupVersionResource,
+	objects []*unstructured.Unstructured,
+	resourceVersion string) []watch.Event {
+	bookmark := &unstructured.Unstructured{}
+	bookmark.SetAPIVersion(res.GroupVersion().String())
+	bookmark.SetResourceVersion(resourceVersion)
+	bookmark.SetAnnotations(map[string]string{cluster.InitialEventsEndAnnotation: "true"})
+
+	var events []watch.Event
+	for _, object := range objects {
+		events = append(events, watch.Event{Type: watch.Added, Object: object.DeepCopy()})
+		bookmark.SetKind(object.GetKind())
+	}
+
+	return append(events, watch.Event{Type: watch.Bookmark, Object: bookmark})
+}
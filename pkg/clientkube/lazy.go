@@ -0,0 +1,171 @@
+package clientkube
+
+import (
+	"context"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/bryanl/clientkube/pkg/cluster"
+)
+
+// resourceContext creates the context a resource is informed with. It is
+// canceled when the informer stops or the resource is stopped.
+func (inf *MemoryStoreInformer) resourceContext(res schema.GroupVersionResource) context.Context {
+	inf.mu.Lock()
+	defer inf.mu.Unlock()
+
+	if cancel, ok := inf.resourceCancels[res]; ok {
+		cancel()
+	}
+
+	ctx, cancel := context.WithCancel(inf.ctx)
+	inf.resourceContexts[res] = ctx
+	inf.resourceCancels[res] = cancel
+
+	return ctx
+}
+
+// resourceDone returns a channel that is closed when the resource stops
+// being informed. The resource is informed until the informer stops if it
+// wasn't started with a context of its own. The caller must hold inf.mu.
+func (inf *MemoryStoreInformer) resourceDone(res schema.GroupVersionResource) <-chan struct{} {
+	if ctx, ok := inf.resourceContexts[res]; ok {
+		return ctx.Done()
+	}
+
+	if inf.ctx != nil {
+		return inf.ctx.Done()
+	}
+
+	return nil
+}
+
+// touch records that a resource was used. A lazy informer starts informing
+// the resource if it isn't already. Other informers don't track use.
+func (inf *MemoryStoreInformer) touch(res schema.GroupVersionResource) {
+	if !inf.lazy {
+		return
+	}
+
+	inf.accessMu.Lock()
+	inf.lastAccess[res] = time.Now()
+	inf.accessMu.Unlock()
+
+	inf.mu.RLock()
+	_, started := inf.syncStates[res]
+	inf.mu.RUnlock()
+
+	if started {
+		return
+	}
+
+	inf.mu.Lock()
+	defer inf.mu.Unlock()
+
+	if !inf.informable[res] || inf.ctx == nil || inf.ctx.Err() != nil {
+		return
+	}
+
+	if _, ok := inf.syncStates[res]; ok {
+		return
+	}
+
+	inf.logger.Info("informing resource on first use", "res", res)
+	inf.setSyncState(res, SyncStatePending, nil)
+
+	inf.goroutine(func() {
+		inf.startResource(res)
+	})
+}
+
+// stopIdleResources stops informing resources that have been idle for the
+// idle timeout until ctx is done.
+func (inf *MemoryStoreInformer) stopIdleResources(ctx context.Context) {
+	interval := inf.idleTimeout / 2
+	if interval <= 0 {
+		interval = time.Second
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			for _, res := range inf.idleResources(now) {
+				inf.logger.Info("stopping idle resource", "res", res)
+				inf.stopResource(res)
+			}
+		}
+	}
+}
+
+// idleResources returns the informed resources that have no watches or
+// event handlers and haven't been used for the idle timeout.
+func (inf *MemoryStoreInformer) idleResources(now time.Time) []schema.GroupVersionResource {
+	inf.mu.RLock()
+	defer inf.mu.RUnlock()
+
+	handled := map[schema.GroupVersionResource]bool{}
+	for _, runner := range inf.eventHandlers {
+		handled[runner.res] = true
+	}
+
+	inf.accessMu.Lock()
+	defer inf.accessMu.Unlock()
+
+	var resources []schema.GroupVersionResource
+	for res := range inf.syncStates {
+		if inf.activeWatches[res] > 0 || handled[res] {
+			continue
+		}
+
+		if now.Sub(inf.lastAccess[res]) < inf.idleTimeout {
+			continue
+		}
+
+		resources = append(resources, res)
+	}
+
+	return resources
+}
+
+// stopResource stops informing a resource and removes its objects from the
+// store. A lazy informer informs the resource again when it is next used.
+func (inf *MemoryStoreInformer) stopResource(res schema.GroupVersionResource) {
+	inf.mu.Lock()
+
+	if cancel, ok := inf.resourceCancels[res]; ok {
+		cancel()
+		delete(inf.resourceCancels, res)
+		delete(inf.resourceContexts, res)
+	}
+
+	if w, ok := inf.apiWatches[res]; ok {
+		w.Stop()
+		delete(inf.apiWatches, res)
+	}
+
+	// clear the synced flag and wake waiters before forgetting the resource.
+	inf.setSyncState(res, SyncStatePending, nil)
+	delete(inf.syncStates, res)
+
+	inf.accessMu.Lock()
+	delete(inf.lastAccess, res)
+	inf.accessMu.Unlock()
+
+	inf.mu.Unlock()
+
+	list, err := inf.store.List(res, cluster.ListOptions{})
+	if err != nil {
+		inf.logger.Error(err, "list store to purge resource", "res", res)
+		return
+	}
+
+	for i := range list.Items {
+		inf.store.Delete(res, &list.Items[i])
+	}
+}
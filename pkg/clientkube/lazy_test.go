@@ -0,0 +1,120 @@
+package clientkube
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+
+	"github.com/bryanl/clientkube/pkg/cluster"
+	"github.com/bryanl/clientkube/pkg/mocks"
+)
+
+func TestMemoryStoreInformer_lazy(t *testing.T) {
+	res := schema.GroupVersionResource{
+		Group:    "group",
+		Version:  "version",
+		Resource: "resource",
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	w := mocks.NewMockWatch(ctrl)
+	w.EXPECT().ResultChan().Return(make(chan watch.Event)).AnyTimes()
+	w.EXPECT().Stop().AnyTimes()
+
+	list := &unstructured.UnstructuredList{
+		Items: []unstructured.Unstructured{
+			{
+				Object: map[string]interface{}{
+					"apiVersion": res.GroupVersion().String(),
+					"kind":       "Resource",
+					"metadata": map[string]interface{}{
+						"name":      "object",
+						"namespace": "default",
+					},
+				},
+			},
+		},
+	}
+
+	client := mocks.NewMockClient(ctrl)
+	client.EXPECT().Resources().Return(cluster.Resources{
+		newResource(res.GroupVersion(), metav1.APIResource{
+			Name:       "resource",
+			Namespaced: true,
+			Kind:       "Resource",
+			Verbs:      metav1.Verbs{"list", "watch"},
+		}),
+	}, nil)
+
+	msi := NewInformer(client, WithLazyInforming(100*time.Millisecond))
+	require.NoError(t, msi.Start(ctx))
+	defer func() {
+		require.NoError(t, msi.Stop())
+	}()
+
+	require.Empty(t, msi.SyncStatus())
+
+	// the first list is passed to the cluster and starts informing the
+	// resource.
+	options := cluster.ListOptions{Namespace: "default"}
+	client.EXPECT().List(gomock.Any(), res, options).Return(list, nil)
+	client.EXPECT().List(gomock.Any(), res, cluster.ListOptions{}).Return(list, nil)
+	client.EXPECT().Watch(gomock.Any(), res, gomock.Any()).Return(w, nil)
+
+	got, err := msi.List(ctx, res, options)
+	require.NoError(t, err)
+	require.Len(t, got.Items, 1)
+
+	require.NoError(t, msi.WaitForSync(ctx, res))
+
+	got, err = msi.List(ctx, res, options)
+	require.NoError(t, err)
+	require.Len(t, got.Items, 1)
+
+	// the idle resource stops being cached and its objects are purged.
+	require.Eventually(t, func() bool {
+		stored, err := msi.store.List(res, cluster.ListOptions{})
+		require.NoError(t, err)
+		return len(msi.SyncStatus()) == 0 && len(stored.Items) == 0
+	}, 2*time.Second, 10*time.Millisecond)
+
+	require.False(t, msi.HasSynced(res))
+}
+
+func TestMemoryStoreInformer_stopResource_beforeSynced(t *testing.T) {
+	res := schema.GroupVersionResource{
+		Group:    "group",
+		Version:  "version",
+		Resource: "resource",
+	}
+
+	msi := NewInformer(nil, WithLazyInforming(time.Minute))
+	msi.ctx = context.Background()
+
+	// the resource is stopped after it was listed, but before it is marked
+	// as synced.
+	ctx := msi.resourceContext(res)
+	msi.setResourceSyncState(ctx, res, SyncStateSyncing, nil)
+	msi.stopResource(res)
+
+	err := msi.setSynced(ctx, res, watch.NewFake())
+	require.True(t, errors.Is(err, context.Canceled), "got %v", err)
+
+	msi.setResourceSyncState(ctx, res, SyncStateFailed, err)
+
+	require.False(t, msi.HasSynced(res))
+	require.Empty(t, msi.SyncStatus())
+}
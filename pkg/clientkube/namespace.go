@@ -0,0 +1,49 @@
+package clientkube
+
+import (
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/bryanl/clientkube/internal/stringutil"
+	"github.com/bryanl/clientkube/pkg/cluster"
+)
+
+// isScopeAllowed returns true if a resource can be cached given the
+// namespaces the informer is limited to.
+func (inf *MemoryStoreInformer) isScopeAllowed(r cluster.Resource) bool {
+	if len(inf.namespaces) == 0 || r.IsNamespaced() {
+		return true
+	}
+
+	res := r.GroupVersionResource()
+	for _, allowed := range inf.clusterScoped {
+		if resourceMatches(allowed, res) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// scopes returns the namespaces a resource is listed and watched in. An
+// empty namespace is the whole cluster.
+func (inf *MemoryStoreInformer) scopes(res schema.GroupVersionResource) []string {
+	inf.mu.RLock()
+	defer inf.mu.RUnlock()
+
+	if len(inf.namespaces) == 0 || !inf.namespaced[res] {
+		return []string{""}
+	}
+
+	return inf.namespaces
+}
+
+// isNamespaceCached returns true if the store has the objects of a resource
+// in a namespace. An empty namespace is served from the store with the
+// objects of every cached namespace. The caller must hold the lock.
+func (inf *MemoryStoreInformer) isNamespaceCached(res schema.GroupVersionResource, namespace string) bool {
+	if len(inf.namespaces) == 0 || namespace == "" || !inf.namespaced[res] {
+		return true
+	}
+
+	return stringutil.Contains(inf.namespaces, namespace)
+}
@@ -0,0 +1,198 @@
+package clientkube
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+
+	"github.com/bryanl/clientkube/pkg/cluster"
+	"github.com/bryanl/clientkube/pkg/mocks"
+)
+
+func TestMemoryStoreInformer_namespaces(t *testing.T) {
+	res := schema.GroupVersionResource{Group: "group", Version: "version", Resource: "resource"}
+	nodes := schema.GroupVersionResource{Version: "v1", Resource: "nodes"}
+	clusterRoles := schema.GroupVersionResource{Group: "rbac", Version: "v1", Resource: "clusterroles"}
+
+	newObject := func(namespace, name, resourceVersion string) unstructured.Unstructured {
+		return unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"apiVersion": res.GroupVersion().String(),
+				"kind":       "Resource",
+				"metadata": map[string]interface{}{
+					"name":            name,
+					"namespace":       namespace,
+					"resourceVersion": resourceVersion,
+				},
+			},
+		}
+	}
+
+	newList := func(resourceVersion string, items ...unstructured.Unstructured) *unstructured.UnstructuredList {
+		list := &unstructured.UnstructuredList{Items: items}
+		list.SetResourceVersion(resourceVersion)
+		return list
+	}
+
+	watchOptions := func(namespace, resourceVersion string) cluster.ListOptions {
+		return cluster.ListOptions{
+			ListOptions: metav1.ListOptions{ResourceVersion: resourceVersion},
+			Namespace:   namespace,
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	newWatch := func() (*mocks.MockWatch, chan watch.Event) {
+		ch := make(chan watch.Event)
+		w := mocks.NewMockWatch(ctrl)
+		w.EXPECT().ResultChan().Return(ch).AnyTimes()
+		w.EXPECT().Stop().AnyTimes()
+		return w, ch
+	}
+
+	wA, chA := newWatch()
+	wB, chB := newWatch()
+	wA2, _ := newWatch()
+	wNodes, _ := newWatch()
+
+	resumed := make(chan struct{})
+
+	newAPIResource := func(name string, namespaced bool) metav1.APIResource {
+		return metav1.APIResource{
+			Name:       name,
+			Namespaced: namespaced,
+			Kind:       "Kind",
+			Verbs:      metav1.Verbs{"list", "watch"},
+		}
+	}
+
+	client := mocks.NewMockClient(ctrl)
+	client.EXPECT().Resources().Return(cluster.Resources{
+		newResource(res.GroupVersion(), newAPIResource(res.Resource, true)),
+		newResource(nodes.GroupVersion(), newAPIResource(nodes.Resource, false)),
+		newResource(clusterRoles.GroupVersion(), newAPIResource(clusterRoles.Resource, false)),
+	}, nil)
+
+	client.EXPECT().
+		List(gomock.Any(), res, cluster.ListOptions{Namespace: "a"}).
+		Return(newList("10", newObject("a", "one", "5")), nil)
+	client.EXPECT().
+		List(gomock.Any(), res, cluster.ListOptions{Namespace: "b"}).
+		Return(newList("11", newObject("b", "two", "6")), nil)
+	client.EXPECT().Watch(gomock.Any(), res, watchOptions("a", "10")).Return(wA, nil)
+	client.EXPECT().Watch(gomock.Any(), res, watchOptions("b", "11")).Return(wB, nil)
+	client.EXPECT().
+		Watch(gomock.Any(), res, watchOptions("a", "12")).
+		DoAndReturn(func(context.Context, schema.GroupVersionResource, cluster.ListOptions) (cluster.Watch, error) {
+			close(resumed)
+			return wA2, nil
+		})
+
+	// allowed cluster-scoped resources are listed across the cluster.
+	client.EXPECT().List(gomock.Any(), nodes, cluster.ListOptions{}).Return(newList("1"), nil)
+	client.EXPECT().Watch(gomock.Any(), nodes, watchOptions("", "1")).Return(wNodes, nil)
+
+	store := NewMemoryStore()
+	msi := NewInformer(client,
+		WithStore(store),
+		WithNamespaces("a", "b"),
+		WithClusterScopedResources(schema.GroupVersionResource{Resource: "nodes"}))
+	require.NoError(t, msi.Start(ctx))
+	defer func() {
+		require.NoError(t, msi.Stop())
+	}()
+
+	require.NoError(t, msi.WaitForSync(ctx))
+	require.False(t, msi.HasSynced(clusterRoles))
+
+	names := func(list *unstructured.UnstructuredList) []string {
+		var got []string
+		for _, item := range list.Items {
+			got = append(got, item.GetNamespace()+"/"+item.GetName()+"@"+item.GetResourceVersion())
+		}
+		sort.Strings(got)
+		return got
+	}
+
+	// listing across the cluster merges the cached namespaces.
+	list, err := msi.List(ctx, res, cluster.ListOptions{})
+	require.NoError(t, err)
+	require.Equal(t, []string{"a/one@5", "b/two@6"}, names(list))
+
+	// namespaces that are not cached are listed from the cluster.
+	client.EXPECT().
+		List(gomock.Any(), res, cluster.ListOptions{Namespace: "c"}).
+		Return(newList("12"), nil)
+	list, err = msi.List(ctx, res, cluster.ListOptions{Namespace: "c"})
+	require.NoError(t, err)
+	require.Empty(t, list.Items)
+
+	// events from every namespace are applied to the store.
+	added := newObject("b", "three", "7")
+	chB <- watch.Event{Type: watch.Added, Object: &added}
+
+	// a namespace watch that ends is resumed on its own.
+	modified := newObject("a", "one", "12")
+	chA <- watch.Event{Type: watch.Modified, Object: &modified}
+	close(chA)
+
+	select {
+	case <-resumed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("watch was not resumed")
+	}
+
+	require.Eventually(t, func() bool {
+		list, err := store.List(res, cluster.ListOptions{})
+		require.NoError(t, err)
+		return len(names(list)) == 3 && names(list)[0] == "a/one@12"
+	}, 5*time.Second, 10*time.Millisecond)
+	require.True(t, msi.HasSynced(res))
+}
+
+func TestMemoryStoreInformer_Watch_uncachedNamespace(t *testing.T) {
+	res := schema.GroupVersionResource{Group: "group", Version: "version", Resource: "resource"}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	source := watch.NewFakeWithChanSize(10, false)
+
+	options := cluster.ListOptions{Namespace: "other"}
+
+	client := mocks.NewMockClient(ctrl)
+	client.EXPECT().Watch(gomock.Any(), res, options).Return(source, nil)
+
+	msi := NewInformer(client, WithNamespaces("a"))
+	msi.namespaced[res] = true
+
+	w, err := msi.Watch(context.Background(), res, options)
+	require.NoError(t, err)
+	defer w.Stop()
+
+	// the store doesn't cache the namespace, so the watch stays on the
+	// cluster once the resource is synced.
+	require.NoError(t, msi.SetSynced(res, nil))
+	require.Equal(t, source, w.(*UpdatableWatcher).GetSource())
+
+	object := newVersionedObject("a", "5")
+	object.SetNamespace("other")
+	source.Add(object)
+
+	e := receiveEvent(t, w)
+	require.Equal(t, watch.Added, e.Type)
+	require.Equal(t, "other", e.Object.(*unstructured.Unstructured).GetNamespace())
+}
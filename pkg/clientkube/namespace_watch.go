@@ -0,0 +1,202 @@
+package clientkube
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/watch"
+
+	"github.com/bryanl/clientkube/pkg/cluster"
+)
+
+// resumeFunc watches a namespace from a resource version.
+type resumeFunc func(namespace, resourceVersion string) (cluster.Watch, error)
+
+// namespaceWatch merges the watches of a resource in several namespaces. A
+// namespace watch that ends is resumed from the last resource version it
+// saw. If a namespace watch reports an error or can't be resumed, the error
+// is sent and the merged watch ends.
+type namespaceWatch struct {
+	ch     chan watch.Event
+	done   chan struct{}
+	resume resumeFunc
+
+	sources map[string]cluster.Watch
+	mu      sync.Mutex
+	once    sync.Once
+	wg      sync.WaitGroup
+}
+
+var _ cluster.Watch = &namespaceWatch{}
+
+// newNamespaceWatch merges watches keyed by namespace. resourceVersions are
+// the versions the watches were started from.
+func newNamespaceWatch(
+	watches map[string]cluster.Watch,
+	resourceVersions map[string]string,
+	resume resumeFunc) *namespaceWatch {
+	w := namespaceWatch{
+		ch:      make(chan watch.Event),
+		done:    make(chan struct{}),
+		resume:  resume,
+		sources: map[string]cluster.Watch{},
+	}
+
+	for namespace, source := range watches {
+		w.sources[namespace] = source
+
+		w.wg.Add(1)
+		go w.run(namespace, source, resourceVersions[namespace])
+	}
+
+	go func() {
+		w.wg.Wait()
+		close(w.ch)
+	}()
+
+	return &w
+}
+
+// Stop stops the namespace watches. It can be called more than once.
+func (w *namespaceWatch) Stop() {
+	w.once.Do(func() {
+		close(w.done)
+
+		w.mu.Lock()
+		defer w.mu.Unlock()
+
+		for namespace, source := range w.sources {
+			source.Stop()
+			delete(w.sources, namespace)
+		}
+	})
+}
+
+// ResultChan returns the merged events.
+func (w *namespaceWatch) ResultChan() <-chan watch.Event {
+	return w.ch
+}
+
+func (w *namespaceWatch) run(namespace string, source cluster.Watch, resourceVersion string) {
+	defer w.wg.Done()
+
+	for {
+		var errEvent *watch.Event
+		resourceVersion, errEvent = w.forward(source, resourceVersion)
+		w.stopSource(namespace)
+
+		if w.isDone() {
+			return
+		}
+
+		if errEvent != nil {
+			w.fail(*errEvent)
+			return
+		}
+
+		next, err := w.resume(namespace, resourceVersion)
+		if err != nil {
+			w.fail(errorEvent(fmt.Errorf("resume watch in namespace %s: %w", namespace, err)))
+			return
+		}
+
+		if !w.setSource(namespace, next) {
+			return
+		}
+
+		source = next
+	}
+}
+
+// forward sends events from a namespace watch until it ends. It returns
+// the last resource version seen, and the error event if the watch failed.
+func (w *namespaceWatch) forward(source cluster.Watch, resourceVersion string) (string, *watch.Event) {
+	for {
+		select {
+		case <-w.done:
+			return resourceVersion, nil
+		case e, ok := <-source.ResultChan():
+			if !ok {
+				return resourceVersion, nil
+			}
+
+			if e.Type == watch.Error {
+				return resourceVersion, &e
+			}
+
+			if accessor, err := meta.Accessor(e.Object); err == nil {
+				if rv := accessor.GetResourceVersion(); rv != "" {
+					resourceVersion = rv
+				}
+			}
+
+			select {
+			case w.ch <- e:
+			case <-w.done:
+				return resourceVersion, nil
+			}
+		}
+	}
+}
+
+// setSource records the current watch for a namespace. It returns false,
+// after stopping source, if the merged watch was stopped.
+func (w *namespaceWatch) setSource(namespace string, source cluster.Watch) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.isDone() {
+		source.Stop()
+		return false
+	}
+
+	w.sources[namespace] = source
+
+	return true
+}
+
+// stopSource stops the current watch for a namespace if it hasn't been
+// stopped.
+func (w *namespaceWatch) stopSource(namespace string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if source, ok := w.sources[namespace]; ok {
+		source.Stop()
+		delete(w.sources, namespace)
+	}
+}
+
+// fail sends an error event and stops the merged watch.
+func (w *namespaceWatch) fail(e watch.Event) {
+	select {
+	case w.ch <- e:
+	case <-w.done:
+	}
+
+	w.Stop()
+}
+
+func (w *namespaceWatch) isDone() bool {
+	select {
+	case <-w.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// errorEvent creates a watch error event for err.
+func errorEvent(err error) watch.Event {
+	var status apierrors.APIStatus
+	if !errors.As(err, &status) {
+		status = apierrors.NewInternalError(err)
+	}
+
+	s := status.Status()
+
+	return watch.Event{Type: watch.Error, Object: &s}
+}
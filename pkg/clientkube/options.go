@@ -1,20 +1,50 @@
 package clientkube
 
 import (
+	"time"
+
 	"github.com/go-logr/logr"
 	"github.com/go-logr/logr/testing"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
 
 	"github.com/bryanl/clientkube/pkg/cluster"
 )
 
 type options struct {
-	logger logr.Logger
-	store  cluster.Store
+	logger           logr.Logger
+	store            cluster.Store
+	eventHistorySize int
+	backoff          wait.Backoff
+	asyncStart       bool
+	filter           resourceFilter
+	lazy             bool
+	idleTimeout      time.Duration
+	namespaces       []string
+	clusterScoped    []schema.GroupVersionResource
+	dynamicDiscovery bool
+	discoveryDelay   time.Duration
+	watchQueueSize   int
+	overflowPolicy   OverflowPolicy
+	overflowTimeout  time.Duration
+	stopTimeout      time.Duration
 }
 
 func currentOptions(list ...Option) options {
 	opts := options{
-		logger: &testing.NullLogger{},
+		logger:           &testing.NullLogger{},
+		eventHistorySize: 1000,
+		watchQueueSize:   100,
+		overflowTimeout:  5 * time.Second,
+		stopTimeout:      30 * time.Second,
+		discoveryDelay:   time.Second,
+		backoff: wait.Backoff{
+			Duration: time.Second,
+			Factor:   2,
+			Jitter:   0.1,
+			Steps:    10,
+			Cap:      time.Minute,
+		},
 	}
 
 	for _, o := range list {
@@ -37,3 +67,138 @@ func WithStore(store cluster.Store) Option {
 		o.store = store
 	}
 }
+
+// WithEventHistorySize sets the number of events a store buffers for each
+// resource, so watches can resume from an older resource version.
+func WithEventHistorySize(size int) Option {
+	return func(o *options) {
+		o.eventHistorySize = size
+	}
+}
+
+// WithBackoff sets the backoff an informer uses when retrying a resource
+// that failed to list.
+func WithBackoff(backoff wait.Backoff) Option {
+	return func(o *options) {
+		o.backoff = backoff
+	}
+}
+
+// WithAsyncStart makes an informer's Start return without waiting for
+// resources to sync.
+func WithAsyncStart() Option {
+	return func(o *options) {
+		o.asyncStart = true
+	}
+}
+
+// WithResources limits an informer to caching the given resources, along
+// with any included by WithGroups or WithCategories. A resource without a
+// version matches every version.
+func WithResources(resources ...schema.GroupVersionResource) Option {
+	return func(o *options) {
+		o.filter.resources = append(o.filter.resources, resources...)
+	}
+}
+
+// WithExcludedResources prevents an informer from caching the given
+// resources. A resource without a version matches every version.
+func WithExcludedResources(resources ...schema.GroupVersionResource) Option {
+	return func(o *options) {
+		o.filter.excluded = append(o.filter.excluded, resources...)
+	}
+}
+
+// WithGroups limits an informer to caching resources in the given API
+// groups, along with any included by WithResources or WithCategories.
+func WithGroups(groups ...string) Option {
+	return func(o *options) {
+		o.filter.groups = append(o.filter.groups, groups...)
+	}
+}
+
+// WithCategories limits an informer to caching resources in the given
+// categories, such as "all", along with any included by WithResources or
+// WithGroups.
+func WithCategories(categories ...string) Option {
+	return func(o *options) {
+		o.filter.categories = append(o.filter.categories, categories...)
+	}
+}
+
+// WithLazyInforming makes an informer start caching a resource when it is
+// first listed or watched instead of in Start. Until the resource is
+// synced, calls are passed to the cluster. A resource that has no watches
+// or event handlers and hasn't been used for idleTimeout stops being
+// cached.
+func WithLazyInforming(idleTimeout time.Duration) Option {
+	return func(o *options) {
+		o.lazy = true
+		o.idleTimeout = idleTimeout
+	}
+}
+
+// WithNamespaces limits an informer to caching objects in the given
+// namespaces, for users who can't list and watch across the cluster. Each
+// namespace is listed and watched separately. Cluster-scoped resources are
+// not cached unless they are allowed with WithClusterScopedResources.
+func WithNamespaces(namespaces ...string) Option {
+	return func(o *options) {
+		o.namespaces = append(o.namespaces, namespaces...)
+	}
+}
+
+// WithClusterScopedResources allows an informer limited with WithNamespaces
+// to cache the given cluster-scoped resources. A resource without a version
+// matches every version.
+func WithClusterScopedResources(resources ...schema.GroupVersionResource) Option {
+	return func(o *options) {
+		o.clusterScoped = append(o.clusterScoped, resources...)
+	}
+}
+
+// WithDynamicDiscovery makes an informer watch CustomResourceDefinitions and
+// APIServices, and rediscover the cluster's resources when they change.
+// Resources that appear are cached, and resources that disappear are
+// stopped and removed from the store.
+func WithDynamicDiscovery() Option {
+	return func(o *options) {
+		o.dynamicDiscovery = true
+	}
+}
+
+// WithDiscoveryDelay sets how long an informer with dynamic discovery waits
+// after a CustomResourceDefinition or APIService change before
+// rediscovering, so a burst of changes causes one rediscovery. The default
+// is one second.
+func WithDiscoveryDelay(delay time.Duration) Option {
+	return func(o *options) {
+		o.discoveryDelay = delay
+	}
+}
+
+// WithWatchQueueSize sets the number of events MemoryStore queues for each
+// watcher. The default is 100.
+func WithWatchQueueSize(size int) Option {
+	return func(o *options) {
+		o.watchQueueSize = size
+	}
+}
+
+// WithOverflowPolicy sets what MemoryStore does when a watcher's queue is
+// full. timeout is how long OverflowBlock waits for room in the queue. The
+// default is OverflowTerminate.
+func WithOverflowPolicy(policy OverflowPolicy, timeout time.Duration) Option {
+	return func(o *options) {
+		o.overflowPolicy = policy
+		o.overflowTimeout = timeout
+	}
+}
+
+// WithStopTimeout sets how long an informer's Stop waits for its goroutines
+// to finish. The default is 30 seconds.
+func WithStopTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.stopTimeout = timeout
+	}
+}
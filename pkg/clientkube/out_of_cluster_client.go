@@ -5,11 +5,16 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"strings"
 	"time"
 
 	"go.uber.org/multierr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/discovery/cached/disk"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/tools/clientcmd"
@@ -21,10 +26,11 @@ import (
 type OutOfClusterClient struct {
 	client          dynamic.Interface
 	dir             string
-	discoveryClient *disk.CachedDiscoveryClient
+	discoveryClient discovery.CachedDiscoveryInterface
 }
 
 var _ cluster.Client = &OutOfClusterClient{}
+var _ cluster.Invalidator = &OutOfClusterClient{}
 
 // NewOutOfClusterClient creates an instance of OutOfClusterClient.
 func NewOutOfClusterClient(kubeconfig string) (*OutOfClusterClient, error) {
@@ -75,7 +81,15 @@ func (c *OutOfClusterClient) Close() error {
 	return err
 }
 
-// Resources lists the resources available in the cluster.
+// Invalidate discards the cached discovery information.
+func (c *OutOfClusterClient) Invalidate() {
+	c.discoveryClient.Invalidate()
+}
+
+// Resources lists the resources available in the cluster, with groups in
+// discovery priority order. Preferred resources never include
+// subresources, so they are merged in from the full resource list of each
+// preferred group version.
 func (c *OutOfClusterClient) Resources() (cluster.Resources, error) {
 	resourceLists, err := c.discoveryClient.ServerPreferredResources()
 	if err != nil {
@@ -90,14 +104,36 @@ func (c *OutOfClusterClient) Resources() (cluster.Resources, error) {
 			return nil, fmt.Errorf("parse group version: %w", err)
 		}
 
-		for _, apiResource := range resourceList.APIResources {
-			list = append(list, newResource(groupVersion, apiResource))
+		apiResources, err := c.withSubresources(resourceList)
+		if err != nil {
+			return nil, err
 		}
+
+		list = append(list, discoveredResources(groupVersion, apiResources)...)
 	}
 
 	return list, nil
 }
 
+// withSubresources appends the subresources of a group version to its
+// preferred resources.
+func (c *OutOfClusterClient) withSubresources(resourceList *metav1.APIResourceList) ([]metav1.APIResource, error) {
+	apiResources := append([]metav1.APIResource{}, resourceList.APIResources...)
+
+	all, err := c.discoveryClient.ServerResourcesForGroupVersion(resourceList.GroupVersion)
+	if err != nil {
+		return nil, fmt.Errorf("get server resources for %s: %w", resourceList.GroupVersion, err)
+	}
+
+	for _, apiResource := range all.APIResources {
+		if strings.Contains(apiResource.Name, "/") {
+			apiResources = append(apiResources, apiResource)
+		}
+	}
+
+	return apiResources, nil
+}
+
 // List lists objects in the cluster.
 func (c *OutOfClusterClient) List(
 	ctx context.Context,
@@ -121,3 +157,144 @@ func (c *OutOfClusterClient) Watch(
 
 	return c.client.Resource(res).Namespace(options.Namespace).Watch(ctx, options.ListOptions)
 }
+
+// Get gets an object or one of its subresources by name.
+func (c *OutOfClusterClient) Get(
+	ctx context.Context,
+	res schema.GroupVersionResource,
+	name string,
+	options cluster.GetOptions) (*unstructured.Unstructured, error) {
+	return c.resourceClient(res, options.Namespace).
+		Get(ctx, name, options.GetOptions, subresources(options.Subresource)...)
+}
+
+// Create creates an object. The object's namespace is used.
+func (c *OutOfClusterClient) Create(
+	ctx context.Context,
+	res schema.GroupVersionResource,
+	object *unstructured.Unstructured,
+	options cluster.CreateOptions) (*unstructured.Unstructured, error) {
+	return c.resourceClient(res, object.GetNamespace()).Create(ctx, object, options.CreateOptions)
+}
+
+// Update updates an object or one of its subresources. The object's
+// namespace is used.
+func (c *OutOfClusterClient) Update(
+	ctx context.Context,
+	res schema.GroupVersionResource,
+	object *unstructured.Unstructured,
+	options cluster.UpdateOptions) (*unstructured.Unstructured, error) {
+	return c.resourceClient(res, object.GetNamespace()).
+		Update(ctx, object, options.UpdateOptions, subresources(options.Subresource)...)
+}
+
+// Patch patches an object or one of its subresources by name.
+func (c *OutOfClusterClient) Patch(
+	ctx context.Context,
+	res schema.GroupVersionResource,
+	name string,
+	patchType types.PatchType,
+	data []byte,
+	options cluster.PatchOptions) (*unstructured.Unstructured, error) {
+	switch patchType {
+	case types.JSONPatchType, types.MergePatchType, types.StrategicMergePatchType:
+	default:
+		return nil, fmt.Errorf("unsupported patch type %q", patchType)
+	}
+
+	return c.resourceClient(res, options.Namespace).
+		Patch(ctx, name, patchType, data, options.PatchOptions, subresources(options.Subresource)...)
+}
+
+// Apply applies an object using server-side apply.
+func (c *OutOfClusterClient) Apply(
+	ctx context.Context,
+	res schema.GroupVersionResource,
+	object *unstructured.Unstructured,
+	options cluster.ApplyOptions) (*unstructured.Unstructured, error) {
+	if options.FieldManager == "" {
+		return nil, fmt.Errorf("field manager is required for apply")
+	}
+
+	data, err := object.MarshalJSON()
+	if err != nil {
+		return nil, fmt.Errorf("marshal object: %w", err)
+	}
+
+	force := options.Force
+	patchOptions := metav1.PatchOptions{
+		FieldManager: options.FieldManager,
+		Force:        &force,
+	}
+
+	if options.DryRun {
+		patchOptions.DryRun = []string{metav1.DryRunAll}
+	}
+
+	return c.resourceClient(res, object.GetNamespace()).
+		Patch(ctx, object.GetName(), types.ApplyPatchType, data, patchOptions)
+}
+
+// Delete deletes an object by name.
+func (c *OutOfClusterClient) Delete(
+	ctx context.Context,
+	res schema.GroupVersionResource,
+	name string,
+	options cluster.DeleteOptions) error {
+	return c.resourceClient(res, options.Namespace).Delete(ctx, name, options.DeleteOptions)
+}
+
+// DeleteCollection deletes the objects matching the list options.
+func (c *OutOfClusterClient) DeleteCollection(
+	ctx context.Context,
+	res schema.GroupVersionResource,
+	options cluster.DeleteOptions,
+	listOptions cluster.ListOptions) error {
+	return c.resourceClient(res, listOptions.Namespace).
+		DeleteCollection(ctx, options.DeleteOptions, listOptions.ListOptions)
+}
+
+// Evict evicts a pod by name.
+func (c *OutOfClusterClient) Evict(
+	ctx context.Context,
+	name string,
+	options cluster.DeleteOptions) error {
+	deleteOptions, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&options.DeleteOptions)
+	if err != nil {
+		return fmt.Errorf("convert delete options: %w", err)
+	}
+
+	eviction := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion":    "policy/v1beta1",
+			"kind":          "Eviction",
+			"deleteOptions": deleteOptions,
+		},
+	}
+	eviction.SetName(name)
+	eviction.SetNamespace(options.Namespace)
+
+	pods := schema.GroupVersionResource{Version: "v1", Resource: "pods"}
+
+	_, err = c.resourceClient(pods, options.Namespace).
+		Create(ctx, eviction, metav1.CreateOptions{}, "eviction")
+	return err
+}
+
+func (c *OutOfClusterClient) resourceClient(res schema.GroupVersionResource, namespace string) dynamic.ResourceInterface {
+	if namespace == "" {
+		return c.client.Resource(res)
+	}
+
+	return c.client.Resource(res).Namespace(namespace)
+}
+
+// subresources converts an optional subresource to the subresources
+// argument of the dynamic client.
+func subresources(subresource string) []string {
+	if subresource == "" {
+		return nil
+	}
+
+	return []string{subresource}
+}
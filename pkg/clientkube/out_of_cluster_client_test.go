@@ -0,0 +1,155 @@
+package clientkube
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/discovery/cached/memory"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	fakedynamic "k8s.io/client-go/dynamic/fake"
+	clienttesting "k8s.io/client-go/testing"
+
+	"github.com/bryanl/clientkube/pkg/cluster"
+)
+
+func TestOutOfClusterClient_Resources(t *testing.T) {
+	fake := &clienttesting.Fake{
+		Resources: []*metav1.APIResourceList{
+			{
+				GroupVersion: "v1",
+				APIResources: []metav1.APIResource{
+					{Name: "pods", Kind: "Pod", Namespaced: true},
+					{Name: "pods/status", Kind: "Pod", Namespaced: true},
+					{Name: "pods/eviction", Group: "policy", Version: "v1beta1", Kind: "Eviction", Namespaced: true},
+				},
+			},
+			{
+				GroupVersion: "apps/v1",
+				APIResources: []metav1.APIResource{
+					{Name: "deployments", Kind: "Deployment", Namespaced: true},
+					{Name: "deployments/scale", Group: "autoscaling", Version: "v1", Kind: "Scale", Namespaced: true},
+				},
+			},
+		},
+	}
+
+	c := &OutOfClusterClient{
+		discoveryClient: memory.NewMemCacheClient(&fakediscovery.FakeDiscovery{Fake: fake}),
+	}
+
+	list, err := c.Resources()
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+
+	subresources := map[schema.GroupVersionResource][]string{}
+	for _, r := range list {
+		subresources[r.GroupVersionResource()] = r.Subresources()
+	}
+
+	require.Equal(t, map[schema.GroupVersionResource][]string{
+		{Version: "v1", Resource: "pods"}:                       {"status", "eviction"},
+		{Group: "apps", Version: "v1", Resource: "deployments"}: {"scale"},
+	}, subresources)
+}
+
+func TestOutOfClusterClient_Update(t *testing.T) {
+	c, fake := newFakeOutOfClusterClient()
+
+	object := &unstructured.Unstructured{}
+	object.SetAPIVersion("v1")
+	object.SetKind("Pod")
+	object.SetNamespace("default")
+	object.SetName("pod")
+
+	_, err := c.Update(context.Background(), podsResource(), object, cluster.UpdateOptions{Subresource: "status"})
+	require.NoError(t, err)
+
+	actions := fake.Actions()
+	require.Len(t, actions, 1)
+
+	action, ok := actions[0].(clienttesting.UpdateAction)
+	require.True(t, ok, "got %T", actions[0])
+	require.Equal(t, "status", action.GetSubresource())
+	require.Equal(t, "default", action.GetNamespace())
+}
+
+func TestOutOfClusterClient_Patch(t *testing.T) {
+	c, fake := newFakeOutOfClusterClient()
+
+	options := cluster.PatchOptions{Namespace: "default", Subresource: "status"}
+	data := []byte(`{"status":{"phase":"Running"}}`)
+
+	_, err := c.Patch(context.Background(), podsResource(), "pod", types.MergePatchType, data, options)
+	require.NoError(t, err)
+
+	actions := fake.Actions()
+	require.Len(t, actions, 1)
+
+	action, ok := actions[0].(clienttesting.PatchAction)
+	require.True(t, ok, "got %T", actions[0])
+	require.Equal(t, "status", action.GetSubresource())
+	require.Equal(t, "default", action.GetNamespace())
+	require.Equal(t, "pod", action.GetName())
+	require.Equal(t, data, action.GetPatch())
+}
+
+func TestOutOfClusterClient_Evict(t *testing.T) {
+	c, fake := newFakeOutOfClusterClient()
+
+	gracePeriod := int64(30)
+	options := cluster.DeleteOptions{
+		DeleteOptions: metav1.DeleteOptions{GracePeriodSeconds: &gracePeriod},
+		Namespace:     "default",
+	}
+
+	require.NoError(t, c.Evict(context.Background(), "pod", options))
+
+	actions := fake.Actions()
+	require.Len(t, actions, 1)
+
+	action, ok := actions[0].(clienttesting.CreateAction)
+	require.True(t, ok, "got %T", actions[0])
+	require.Equal(t, podsResource(), action.GetResource())
+	require.Equal(t, "eviction", action.GetSubresource())
+	require.Equal(t, "default", action.GetNamespace())
+
+	eviction, ok := action.GetObject().(*unstructured.Unstructured)
+	require.True(t, ok, "got %T", action.GetObject())
+	require.Equal(t, "policy/v1beta1", eviction.GetAPIVersion())
+	require.Equal(t, "Eviction", eviction.GetKind())
+	require.Equal(t, "pod", eviction.GetName())
+	require.Equal(t, "default", eviction.GetNamespace())
+
+	got, found, err := unstructured.NestedInt64(eviction.Object, "deleteOptions", "gracePeriodSeconds")
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Equal(t, gracePeriod, got)
+}
+
+// newFakeOutOfClusterClient creates an OutOfClusterClient backed by a fake
+// dynamic client that records actions and echoes back their objects.
+func newFakeOutOfClusterClient() (*OutOfClusterClient, *fakedynamic.FakeDynamicClient) {
+	fake := fakedynamic.NewSimpleDynamicClient(runtime.NewScheme())
+	fake.PrependReactor("*", "*", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		if a, ok := action.(clienttesting.UpdateAction); ok {
+			return true, a.GetObject(), nil
+		}
+
+		object := &unstructured.Unstructured{}
+		object.SetAPIVersion("v1")
+		object.SetKind("Pod")
+		return true, object, nil
+	})
+
+	return &OutOfClusterClient{client: fake}, fake
+}
+
+func podsResource() schema.GroupVersionResource {
+	return schema.GroupVersionResource{Version: "v1", Resource: "pods"}
+}
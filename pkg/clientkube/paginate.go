@@ -0,0 +1,128 @@
+package clientkube
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// continueTokenVersion identifies continue tokens issued by MemoryStore.
+const continueTokenVersion = "clientkube.memorystore/v1"
+
+// continueToken is the decoded form of a MemoryStore continue token. The
+// encoded form is opaque to callers.
+type continueToken struct {
+	Version   string `json:"v"`
+	Resource  string `json:"res"`
+	Namespace string `json:"ns"`
+	Name      string `json:"name"`
+}
+
+func encodeContinueToken(res schema.GroupVersionResource, last storeKey) (string, error) {
+	data, err := json.Marshal(continueToken{
+		Version:   continueTokenVersion,
+		Resource:  res.String(),
+		Namespace: last.namespace,
+		Name:      last.name,
+	})
+	if err != nil {
+		return "", fmt.Errorf("marshal continue token: %w", err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+func decodeContinueToken(s string) (continueToken, bool) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return continueToken{}, false
+	}
+
+	var token continueToken
+	if err := json.Unmarshal(data, &token); err != nil {
+		return continueToken{}, false
+	}
+
+	if token.Version != continueTokenVersion {
+		return continueToken{}, false
+	}
+
+	return token, true
+}
+
+// isStoreContinueToken returns true if a continue token was issued by
+// MemoryStore rather than the API server.
+func isStoreContinueToken(s string) bool {
+	_, ok := decodeContinueToken(s)
+	return ok
+}
+
+// sortObjects sorts objects by namespace and name.
+func sortObjects(objects []*unstructured.Unstructured) {
+	sort.Slice(objects, func(i, j int) bool {
+		return keyLess(storeKeyFor(objects[i]), storeKeyFor(objects[j]))
+	})
+}
+
+func keyLess(a, b storeKey) bool {
+	if a.namespace != b.namespace {
+		return a.namespace < b.namespace
+	}
+
+	return a.name < b.name
+}
+
+// paginate returns the page of sorted objects selected by limit and the
+// continue token, and sets the continue token and remaining item count on
+// the list when more objects are available.
+func paginate(
+	res schema.GroupVersionResource,
+	objects []*unstructured.Unstructured,
+	limit int64,
+	continueValue string,
+	list *unstructured.UnstructuredList) error {
+	start := 0
+
+	if continueValue != "" {
+		token, ok := decodeContinueToken(continueValue)
+		if !ok {
+			return apierrors.NewBadRequest("invalid continue token")
+		}
+
+		if token.Resource != res.String() {
+			return apierrors.NewBadRequest(fmt.Sprintf("continue token is for %s", token.Resource))
+		}
+
+		last := storeKey{namespace: token.Namespace, name: token.Name}
+		start = sort.Search(len(objects), func(i int) bool {
+			return keyLess(last, storeKeyFor(objects[i]))
+		})
+	}
+
+	end := len(objects)
+	if limit > 0 && int64(end-start) > limit {
+		end = start + int(limit)
+	}
+
+	for _, object := range objects[start:end] {
+		list.Items = append(list.Items, *object)
+	}
+
+	if end < len(objects) {
+		token, err := encodeContinueToken(res, storeKeyFor(objects[end-1]))
+		if err != nil {
+			return err
+		}
+
+		remaining := int64(len(objects) - end)
+		list.SetContinue(token)
+		list.SetRemainingItemCount(&remaining)
+	}
+
+	return nil
+}
@@ -1,6 +1,8 @@
 package clientkube
 
 import (
+	"strings"
+
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 
@@ -13,11 +15,23 @@ type resource struct {
 	name             string
 	categories       []string
 	isNamespaced     bool
+	shortNames       []string
+	singularName     string
+	subresources     []string
 }
 
 var _ cluster.Resource = &resource{}
 
+// newResource creates a resource from discovery. The API resource's group
+// and version, if set, override the group version it was listed in.
 func newResource(groupVersion schema.GroupVersion, apiResource metav1.APIResource) *resource {
+	if apiResource.Group != "" {
+		groupVersion.Group = apiResource.Group
+	}
+	if apiResource.Version != "" {
+		groupVersion.Version = apiResource.Version
+	}
+
 	r := resource{
 		groupVersionKind: schema.GroupVersionKind{
 			Group:   groupVersion.Group,
@@ -28,6 +42,8 @@ func newResource(groupVersion schema.GroupVersion, apiResource metav1.APIResourc
 		name:         apiResource.Name,
 		categories:   apiResource.Categories,
 		isNamespaced: apiResource.Namespaced,
+		shortNames:   apiResource.ShortNames,
+		singularName: apiResource.SingularName,
 	}
 
 	return &r
@@ -60,3 +76,52 @@ func (r resource) Categories() []string {
 func (r resource) IsNamespaced() bool {
 	return r.isNamespaced
 }
+
+func (r resource) ShortNames() []string {
+	return r.shortNames
+}
+
+// SingularName returns the singular name for the resource. Discovery may
+// leave it empty, so the lower case kind is used instead.
+func (r resource) SingularName() string {
+	if r.singularName == "" {
+		return strings.ToLower(r.groupVersionKind.Kind)
+	}
+
+	return r.singularName
+}
+
+func (r resource) Subresources() []string {
+	return r.subresources
+}
+
+// discoveredResources creates the resources in a discovered group version.
+// Subresources, like pods/status, are reported by their resource rather
+// than listed.
+func discoveredResources(groupVersion schema.GroupVersion, apiResources []metav1.APIResource) cluster.Resources {
+	var list cluster.Resources
+	byName := map[string]*resource{}
+
+	for _, apiResource := range apiResources {
+		if strings.Contains(apiResource.Name, "/") {
+			continue
+		}
+
+		r := newResource(groupVersion, apiResource)
+		byName[apiResource.Name] = r
+		list = append(list, r)
+	}
+
+	for _, apiResource := range apiResources {
+		parts := strings.SplitN(apiResource.Name, "/", 2)
+		if len(parts) != 2 {
+			continue
+		}
+
+		if r, ok := byName[parts[0]]; ok {
+			r.subresources = append(r.subresources, parts[1])
+		}
+	}
+
+	return list
+}
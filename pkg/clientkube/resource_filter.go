@@ -0,0 +1,61 @@
+package clientkube
+
+import (
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/bryanl/clientkube/internal/stringutil"
+	"github.com/bryanl/clientkube/pkg/cluster"
+)
+
+// resourceFilter selects the resources an informer caches.
+type resourceFilter struct {
+	resources  []schema.GroupVersionResource
+	excluded   []schema.GroupVersionResource
+	groups     []string
+	categories []string
+}
+
+// matches returns true if a resource should be cached. If any resources,
+// groups, or categories are included, the resource must match one of them.
+// Excluded resources are never cached.
+func (f resourceFilter) matches(r cluster.Resource) bool {
+	res := r.GroupVersionResource()
+
+	for _, excluded := range f.excluded {
+		if resourceMatches(excluded, res) {
+			return false
+		}
+	}
+
+	if len(f.resources) == 0 && len(f.groups) == 0 && len(f.categories) == 0 {
+		return true
+	}
+
+	for _, included := range f.resources {
+		if resourceMatches(included, res) {
+			return true
+		}
+	}
+
+	if stringutil.Contains(f.groups, res.Group) {
+		return true
+	}
+
+	for _, category := range r.Categories() {
+		if stringutil.Contains(f.categories, category) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// resourceMatches returns true if res matches pattern. A pattern without a
+// version matches every version of the resource.
+func resourceMatches(pattern, res schema.GroupVersionResource) bool {
+	if pattern.Version == "" {
+		return pattern.GroupResource() == res.GroupResource()
+	}
+
+	return pattern == res
+}
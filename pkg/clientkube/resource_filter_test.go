@@ -0,0 +1,93 @@
+package clientkube
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestResourceFilter_matches(t *testing.T) {
+	pods := newResource(schema.GroupVersion{Version: "v1"}, metav1.APIResource{
+		Name:       "pods",
+		Kind:       "Pod",
+		Categories: []string{"all"},
+	})
+	events := newResource(schema.GroupVersion{Version: "v1"}, metav1.APIResource{
+		Name: "events",
+		Kind: "Event",
+	})
+	deployments := newResource(schema.GroupVersion{Group: "apps", Version: "v1"}, metav1.APIResource{
+		Name:       "deployments",
+		Kind:       "Deployment",
+		Categories: []string{"all"},
+	})
+	leases := newResource(schema.GroupVersion{Group: "coordination.k8s.io", Version: "v1"}, metav1.APIResource{
+		Name: "leases",
+		Kind: "Lease",
+	})
+
+	all := []*resource{pods, events, deployments, leases}
+
+	tests := []struct {
+		name    string
+		options []Option
+		wanted  []*resource
+	}{
+		{
+			name:   "no filters",
+			wanted: all,
+		},
+		{
+			name:    "include resources",
+			options: []Option{WithResources(pods.GroupVersionResource())},
+			wanted:  []*resource{pods},
+		},
+		{
+			name: "include resources in any version",
+			options: []Option{
+				WithResources(schema.GroupVersionResource{Group: "apps", Resource: "deployments"}),
+			},
+			wanted: []*resource{deployments},
+		},
+		{
+			name:    "exclude resources",
+			options: []Option{WithExcludedResources(events.GroupVersionResource(), leases.GroupVersionResource())},
+			wanted:  []*resource{pods, deployments},
+		},
+		{
+			name:    "include groups",
+			options: []Option{WithGroups("apps", "coordination.k8s.io")},
+			wanted:  []*resource{deployments, leases},
+		},
+		{
+			name:    "include categories",
+			options: []Option{WithCategories("all")},
+			wanted:  []*resource{pods, deployments},
+		},
+		{
+			name: "combine includes and excludes",
+			options: []Option{
+				WithCategories("all"),
+				WithGroups("coordination.k8s.io"),
+				WithExcludedResources(schema.GroupVersionResource{Resource: "pods"}),
+			},
+			wanted: []*resource{deployments, leases},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			opts := currentOptions(test.options...)
+
+			var actual []*resource
+			for _, r := range all {
+				if opts.filter.matches(r) {
+					actual = append(actual, r)
+				}
+			}
+
+			require.Equal(t, test.wanted, actual)
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package clientkube
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func Test_newResource(t *testing.T) {
+	tests := []struct {
+		name         string
+		groupVersion schema.GroupVersion
+		apiResource  metav1.APIResource
+		wantedGVK    schema.GroupVersionKind
+		wantedGVR    schema.GroupVersionResource
+		wantedName   string
+	}{
+		{
+			name:         "resource",
+			groupVersion: schema.GroupVersion{Group: "apps", Version: "v1"},
+			apiResource: metav1.APIResource{
+				Name:         "deployments",
+				SingularName: "deployment",
+				Kind:         "Deployment",
+			},
+			wantedGVK:  schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"},
+			wantedGVR:  schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"},
+			wantedName: "deployment",
+		},
+		{
+			name:         "resource without a singular name",
+			groupVersion: schema.GroupVersion{Version: "v1"},
+			apiResource: metav1.APIResource{
+				Name: "pods",
+				Kind: "Pod",
+			},
+			wantedGVK:  schema.GroupVersionKind{Version: "v1", Kind: "Pod"},
+			wantedGVR:  schema.GroupVersionResource{Version: "v1", Resource: "pods"},
+			wantedName: "pod",
+		},
+		{
+			name:         "resource with group and version overrides",
+			groupVersion: schema.GroupVersion{Group: "apps", Version: "v1"},
+			apiResource: metav1.APIResource{
+				Name:    "deployments/scale",
+				Group:   "autoscaling",
+				Version: "v1",
+				Kind:    "Scale",
+			},
+			wantedGVK:  schema.GroupVersionKind{Group: "autoscaling", Version: "v1", Kind: "Scale"},
+			wantedGVR:  schema.GroupVersionResource{Group: "autoscaling", Version: "v1", Resource: "deployments/scale"},
+			wantedName: "scale",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := newResource(test.groupVersion, test.apiResource)
+			require.Equal(t, test.wantedGVK, r.GroupVersionKind())
+			require.Equal(t, test.wantedGVR, r.GroupVersionResource())
+			require.Equal(t, test.wantedName, r.SingularName())
+		})
+	}
+}
+
+func Test_discoveredResources(t *testing.T) {
+	groupVersion := schema.GroupVersion{Group: "apps", Version: "v1"}
+
+	list := discoveredResources(groupVersion, []metav1.APIResource{
+		{Name: "deployments", Kind: "Deployment", Verbs: metav1.Verbs{"list", "watch"}},
+		{Name: "deployments/scale", Group: "autoscaling", Version: "v1", Kind: "Scale"},
+		{Name: "deployments/status", Kind: "Deployment"},
+		{Name: "replicasets", Kind: "ReplicaSet"},
+		{Name: "missing/status", Kind: "Missing"},
+	})
+
+	require.Len(t, list, 2)
+
+	require.Equal(t, groupVersion.WithResource("deployments"), list[0].GroupVersionResource())
+	require.Equal(t, []string{"scale", "status"}, list[0].Subresources())
+
+	require.Equal(t, groupVersion.WithResource("replicasets"), list[1].GroupVersionResource())
+	require.Empty(t, list[1].Subresources())
+}
@@ -0,0 +1,43 @@
+package clientkube
+
+import "strconv"
+
+// parseResourceVersion parses a resource version. Resource versions are
+// opaque, but the API server uses integers, and so does this package.
+func parseResourceVersion(rv string) (uint64, bool) {
+	if rv == "" {
+		return 0, false
+	}
+
+	n, err := strconv.ParseUint(rv, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+
+	return n, true
+}
+
+// isResourceVersionAtLeast returns true if actual is the same as or newer
+// than wanted. Resource versions that can't be parsed are compared for
+// equality.
+func isResourceVersionAtLeast(actual, wanted string) bool {
+	a, aOK := parseResourceVersion(actual)
+	w, wOK := parseResourceVersion(wanted)
+	if !aOK || !wOK {
+		return actual == wanted
+	}
+
+	return a >= w
+}
+
+// isResourceVersionNewer returns true if a is newer than b. Resource
+// versions that can't be parsed are never newer.
+func isResourceVersionNewer(a, b string) bool {
+	x, xOK := parseResourceVersion(a)
+	y, yOK := parseResourceVersion(b)
+	if !xOK || !yOK {
+		return false
+	}
+
+	return x > y
+}
@@ -0,0 +1,170 @@
+package clientkube
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/bryanl/clientkube/pkg/cluster"
+)
+
+// defaultFieldPaths are field selector labels supported by every resource.
+var defaultFieldPaths = map[string]string{
+	"metadata.name":      "metadata.name",
+	"metadata.namespace": "metadata.namespace",
+}
+
+// resourceFieldPaths are the field selector labels the API server supports
+// for specific resources, mapped to the path of the field in the object.
+var resourceFieldPaths = map[schema.GroupResource]map[string]string{
+	{Resource: "pods"}: {
+		"spec.nodeName":            "spec.nodeName",
+		"spec.restartPolicy":       "spec.restartPolicy",
+		"spec.schedulerName":       "spec.schedulerName",
+		"spec.serviceAccountName":  "spec.serviceAccountName",
+		"status.phase":             "status.phase",
+		"status.podIP":             "status.podIP",
+		"status.nominatedNodeName": "status.nominatedNodeName",
+	},
+	{Resource: "nodes"}: {
+		"spec.unschedulable": "spec.unschedulable",
+	},
+	{Resource: "events"}: {
+		"involvedObject.kind":            "involvedObject.kind",
+		"involvedObject.namespace":       "involvedObject.namespace",
+		"involvedObject.name":            "involvedObject.name",
+		"involvedObject.uid":             "involvedObject.uid",
+		"involvedObject.apiVersion":      "involvedObject.apiVersion",
+		"involvedObject.resourceVersion": "involvedObject.resourceVersion",
+		"involvedObject.fieldPath":       "involvedObject.fieldPath",
+		"reason":                         "reason",
+		"source":                         "source.component",
+		"type":                           "type",
+	},
+	{Resource: "secrets"}: {
+		"type": "type",
+	},
+	{Resource: "namespaces"}: {
+		"status.phase": "status.phase",
+	},
+	{Resource: "replicationcontrollers"}: {
+		"status.replicas": "status.replicas",
+	},
+	{Group: "apps", Resource: "replicasets"}: {
+		"status.replicas": "status.replicas",
+	},
+	{Group: "batch", Resource: "jobs"}: {
+		"status.successful": "status.successful",
+	},
+}
+
+// listMatcher matches objects against the namespace, label selector and
+// field selector in list options.
+type listMatcher struct {
+	namespace  string
+	label      labels.Selector
+	field      fields.Selector
+	fieldPaths map[string]string
+}
+
+// newListMatcher creates a listMatcher for a resource. It returns an error if
+// a selector can't be parsed or the field selector uses a field that is not
+// supported for the resource.
+func newListMatcher(res schema.GroupVersionResource, options cluster.ListOptions) (*listMatcher, error) {
+	m := listMatcher{
+		namespace:  options.Namespace,
+		label:      labels.Everything(),
+		field:      fields.Everything(),
+		fieldPaths: map[string]string{},
+	}
+
+	for k, v := range defaultFieldPaths {
+		m.fieldPaths[k] = v
+	}
+
+	for k, v := range resourceFieldPaths[res.GroupResource()] {
+		m.fieldPaths[k] = v
+	}
+
+	if s := options.LabelSelector; s != "" {
+		selector, err := labels.Parse(s)
+		if err != nil {
+			return nil, apierrors.NewBadRequest(fmt.Sprintf("parse label selector: %v", err))
+		}
+		m.label = selector
+	}
+
+	if s := options.FieldSelector; s != "" {
+		selector, err := fields.ParseSelector(s)
+		if err != nil {
+			return nil, apierrors.NewBadRequest(fmt.Sprintf("parse field selector: %v", err))
+		}
+
+		for _, r := range selector.Requirements() {
+			if _, ok := m.fieldPaths[r.Field]; !ok {
+				return nil, apierrors.NewBadRequest(
+					fmt.Sprintf("field label not supported for %s: %s", res.GroupResource(), r.Field))
+			}
+		}
+
+		m.field = selector
+	}
+
+	return &m, nil
+}
+
+// matches returns true if object matches the list options.
+func (m *listMatcher) matches(object runtime.Object) bool {
+	u, ok := object.(*unstructured.Unstructured)
+	if !ok {
+		return false
+	}
+
+	if m.namespace != "" && m.namespace != u.GetNamespace() {
+		return false
+	}
+
+	if !m.label.Empty() && !m.label.Matches(labels.Set(u.GetLabels())) {
+		return false
+	}
+
+	if !m.field.Empty() && !m.field.Matches(m.fieldSet(u)) {
+		return false
+	}
+
+	return true
+}
+
+func (m *listMatcher) fieldSet(u *unstructured.Unstructured) fields.Set {
+	set := fields.Set{}
+
+	for label, path := range m.fieldPaths {
+		v, found, err := unstructured.NestedFieldNoCopy(u.Object, strings.Split(path, ".")...)
+		if err != nil || !found {
+			set[label] = ""
+			continue
+		}
+
+		switch t := v.(type) {
+		case string:
+			set[label] = t
+		case bool:
+			set[label] = strconv.FormatBool(t)
+		case int64:
+			set[label] = strconv.FormatInt(t, 10)
+		case float64:
+			set[label] = strconv.FormatFloat(t, 'f', -1, 64)
+		default:
+			set[label] = fmt.Sprintf("%v", t)
+		}
+	}
+
+	return set
+}
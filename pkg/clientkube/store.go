@@ -3,12 +3,13 @@ package clientkube
 import (
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/go-logr/logr"
-	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/rand"
 	"k8s.io/apimachinery/pkg/watch"
 
@@ -24,6 +25,8 @@ type event struct {
 	watch.Event
 
 	Resource schema.GroupVersionResource
+	// Old is the object that was replaced by a modification, if any.
+	Old runtime.Object
 }
 
 type memoryStoreResData map[storeKey]*unstructured.Unstructured
@@ -31,14 +34,27 @@ type memoryStoreData map[schema.GroupVersionResource]memoryStoreResData
 
 // MemoryStore is a memory store. It stores objects in memory.
 type MemoryStore struct {
-	data memoryStoreData
+	data    memoryStoreData
+	history *eventHistory
+	indexes map[schema.GroupVersionResource]map[string]*storeIndex
+	uids    map[types.UID]map[schema.GroupVersionResource]storeKey
 
-	updateCh chan event
-	watchers map[string]chan event
+	watchers map[string]*storeWatcher
+
+	queueSize       int
+	overflowPolicy  OverflowPolicy
+	overflowTimeout time.Duration
+	overflows       uint64
 
 	logger logr.Logger
 
 	mu sync.RWMutex
+
+	// writeMu serializes writers. A writer queues the events that didn't
+	// fit in a watcher's queue in blocked, and waits for them after it
+	// releases mu, so reads aren't blocked by a slow watcher.
+	writeMu sync.Mutex
+	blocked []blockedEvent
 }
 
 var _ cluster.Store = &MemoryStore{}
@@ -49,9 +65,16 @@ func NewMemoryStore(optionList ...Option) *MemoryStore {
 
 	s := MemoryStore{
 		data:     memoryStoreData{},
-		updateCh: make(chan event, 100),
-		watchers: map[string]chan event{},
-		logger:   opts.logger.WithValues("component", "MemoryStore"),
+		history:  newEventHistory(opts.eventHistorySize),
+		indexes:  map[schema.GroupVersionResource]map[string]*storeIndex{},
+		uids:     map[types.UID]map[schema.GroupVersionResource]storeKey{},
+		watchers: map[string]*storeWatcher{},
+
+		queueSize:       opts.watchQueueSize,
+		overflowPolicy:  opts.overflowPolicy,
+		overflowTimeout: opts.overflowTimeout,
+
+		logger: opts.logger.WithValues("component", "MemoryStore"),
 	}
 
 	return &s
@@ -59,6 +82,10 @@ func NewMemoryStore(optionList ...Option) *MemoryStore {
 
 // Add adds an object to the memory store.
 func (s *MemoryStore) Add(res schema.GroupVersionResource, object runtime.Object) {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	defer s.enqueueBlocked()
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -74,14 +101,22 @@ func (s *MemoryStore) Add(res schema.GroupVersionResource, object runtime.Object
 		m = memoryStoreResData{}
 	}
 
-	m[s.key(u)] = u
+	key := s.key(u)
+	old := m[key]
+
+	m[key] = u
 	s.data[res] = m
+	s.updateIndexes(res, key, old, u)
 
-	s.sendUpdate(res, object, watch.Added)
+	s.sendUpdate(res, object, nil, watch.Added)
 }
 
 // Update updates the object in the memory store.
 func (s *MemoryStore) Update(res schema.GroupVersionResource, object runtime.Object) {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	defer s.enqueueBlocked()
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -97,41 +132,97 @@ func (s *MemoryStore) Update(res schema.GroupVersionResource, object runtime.Obj
 		m = memoryStoreResData{}
 	}
 
-	m[s.key(u)] = u
+	key := s.key(u)
+	old := m[key]
+
+	m[key] = u
 	s.data[res] = m
+	s.updateIndexes(res, key, old, u)
+
+	var oldObject runtime.Object
+	if old != nil {
+		oldObject = old
+	}
 
-	s.sendUpdate(res, object, watch.Modified)
+	s.sendUpdate(res, object, oldObject, watch.Modified)
 }
 
-func (s *MemoryStore) sendUpdate(res schema.GroupVersionResource, object runtime.Object, eventType watch.EventType) {
-	for _, ch := range s.watchers {
-		ch <- event{
+func (s *MemoryStore) sendUpdate(res schema.GroupVersionResource, object, old runtime.Object, eventType watch.EventType) {
+	recorded := event{
+		Event: watch.Event{
+			Type:   eventType,
+			Object: object.DeepCopyObject(),
+		},
+		Resource: res,
+	}
+
+	if old != nil {
+		recorded.Old = old.DeepCopyObject()
+	}
+
+	s.history.record(recorded)
+
+	for id, sw := range s.watchers {
+		if sw.res != res {
+			continue
+		}
+
+		e := event{
 			Event: watch.Event{
 				Type:   eventType,
 				Object: object.DeepCopyObject(),
 			},
 			Resource: res,
 		}
+
+		if old != nil {
+			e.Old = old.DeepCopyObject()
+		}
+
+		if s.enqueue(sw, e) {
+			continue
+		}
+
+		if s.overflowPolicy == OverflowBlock {
+			s.blocked = append(s.blocked, blockedEvent{id: id, sw: sw, e: e})
+			continue
+		}
+
+		s.terminateWatcher(id, sw)
 	}
 }
 
-func (s *MemoryStore) onUpdate(fn func(ch <-chan event)) {
+// addWatcher registers a watcher for a resource. The caller must hold the
+// lock.
+func (s *MemoryStore) addWatcher(res schema.GroupVersionResource) (string, *storeWatcher) {
 	id := rand.String(16)
-	ch := make(chan event)
+	sw := &storeWatcher{
+		res:   res,
+		queue: make(chan event, s.queueSize),
+		done:  make(chan struct{}),
+	}
 
-	s.mu.Lock()
-	s.watchers[id] = ch
-	s.mu.Unlock()
+	s.watchers[id] = sw
 
-	fn(ch)
+	return id, sw
+}
 
+// removeWatcher unregisters a watcher. A writer waiting for room in its
+// queue stops waiting.
+func (s *MemoryStore) removeWatcher(id string, sw *storeWatcher) {
 	s.mu.Lock()
 	delete(s.watchers, id)
 	s.mu.Unlock()
+
+	close(sw.done)
 }
 
 // Delete deletes the object from the memory store.
 func (s *MemoryStore) Delete(res schema.GroupVersionResource, object runtime.Object) {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	defer s.enqueueBlocked()
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -147,7 +238,11 @@ func (s *MemoryStore) Delete(res schema.GroupVersionResource, object runtime.Obj
 		return
 	}
 
-	delete(m, s.key(u))
+	key := s.key(u)
+	old := m[key]
+
+	delete(m, key)
+	s.updateIndexes(res, key, old, nil)
 
 	s.data[res] = m
 
@@ -155,90 +250,189 @@ func (s *MemoryStore) Delete(res schema.GroupVersionResource, object runtime.Obj
 		delete(s.data, res)
 	}
 
-	s.sendUpdate(res, u, watch.Deleted)
+	s.sendUpdate(res, u, nil, watch.Deleted)
 }
 
-// List lists objects in a resource.
-// TODO: support all the list option features
+// List lists objects in a resource. Objects are filtered by the namespace,
+// label selector, and field selector in the list options, and are sorted by
+// namespace and name. If the list options have a limit, the list is paged
+// and its continue token can be used to fetch the next page. The list's
+// resource version is the latest resource version seen for the resource.
 func (s *MemoryStore) List(res schema.GroupVersionResource, options cluster.ListOptions) (*unstructured.UnstructuredList, error) {
+	matcher, err := newListMatcher(res, options)
+	if err != nil {
+		return nil, err
+	}
+
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
 	list := &unstructured.UnstructuredList{}
 
-	m, ok := s.data[res]
-	if !ok {
-		return list, nil
+	// a resource without objects still has a resource version, and its
+	// continue token is still checked.
+	var objects []*unstructured.Unstructured
+	for _, v := range s.data[res] {
+		if matcher.matches(v) {
+			objects = append(objects, v)
+		}
 	}
 
-	if options.Namespace == "" {
-		for _, v := range m {
-			list.Items = append(list.Items, *v)
-		}
+	sortObjects(objects)
 
-		return list, nil
+	if rv := s.history.resourceVersion(res); rv != "" {
+		list.SetResourceVersion(rv)
 	}
 
-	for _, v := range m {
-		if v.GetNamespace() == options.Namespace {
-			list.Items = append(list.Items, *v)
-		}
+	if err := paginate(res, objects, options.Limit, options.Continue, list); err != nil {
+		return nil, err
 	}
 
 	return list, nil
 }
 
+// Watch watches a resource for changes. Events are filtered by the
+// namespace, label selector, and field selector in the list options. Like
+// the API server, an object modified so it starts or stops matching the
+// selectors is reported as added or deleted. If the list options have a
+// resource version, buffered events newer than it are sent first; if the
+// version is older than the buffered events, a resource expired error is
+// returned. If the list options send initial events, an added event for
+// each matching object is sent first instead, followed by a bookmark
+// annotated with cluster.InitialEventsEndAnnotation. The objects are read
+// in the same critical section that registers the watch.
 func (s *MemoryStore) Watch(res schema.GroupVersionResource, options cluster.ListOptions) (cluster.Watch, error) {
-	ch := make(chan watch.Event)
-	stopCh := make(chan bool, 1)
-	w := NewWatcher(ch, stopCh)
-	setupCh := make(chan bool, 1)
+	matcher, err := newListMatcher(res, options)
+	if err != nil {
+		return nil, err
+	}
+
+	w := NewWatcher(make(chan watch.Event), make(chan bool))
 
 	s.logger.Info("memory store watch",
 		"schema", res,
 		"options", options)
 
-	go func() {
-		s.onUpdate(func(updateCh <-chan event) {
-			setupCh <- true
-			done := false
-			for !done {
-				select {
-				case <-stopCh:
-					done = true
-				case e := <-updateCh:
-					if res.String() == e.Resource.String() && s.isListOptionMatch(e.Object, options) {
-						ch <- e.Event
-					}
-				}
-			}
+	// the replayed events are gathered while the watcher is registered, so
+	// no event is missed or repeated between the two.
+	var replay []watch.Event
+
+	s.mu.Lock()
+	if options.SendInitialEvents {
+		replay = s.initialEvents(res, matcher)
+	} else {
+		events, err := s.history.since(res, options.ResourceVersion)
+		if err != nil {
+			s.mu.Unlock()
+			return nil, err
+		}
 
-			close(ch)
-			close(stopCh)
-		})
-	}()
+		for _, e := range events {
+			if matched, ok := s.matchEvent(e, matcher); ok {
+				replay = append(replay, matched)
+			}
+		}
+	}
+	id, sw := s.addWatcher(res)
+	s.mu.Unlock()
 
-	<-setupCh
+	go s.forward(w, id, sw, replay, matcher)
 
 	return w, nil
 }
 
-func (s *MemoryStore) isListOptionMatch(object runtime.Object, options cluster.ListOptions) bool {
-	accessor, err := meta.Accessor(object)
-	if err != nil {
-		return false
+// forward sends replayed and queued events to a watcher until it is
+// stopped. The watcher is unregistered before its result channel is
+// closed.
+func (s *MemoryStore) forward(
+	w *Watcher,
+	id string,
+	sw *storeWatcher,
+	replay []watch.Event,
+	matcher *listMatcher) {
+	defer close(w.ch)
+	defer s.removeWatcher(id, sw)
+
+	send := func(e watch.Event) bool {
+		select {
+		case <-w.stopCh:
+			return false
+		case w.ch <- e:
+			return true
+		}
+	}
+
+	for _, e := range replay {
+		if !send(e) {
+			return
+		}
+	}
+
+	for {
+		select {
+		case <-w.stopCh:
+			return
+		case e, ok := <-sw.queue:
+			if !ok {
+				// the watcher fell behind and was terminated.
+				send(overflowEvent())
+				return
+			}
+
+			if matched, ok := s.matchEvent(e, matcher); ok {
+				if !send(matched) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// initialEvents returns an added event for each object matched by matcher,
+// sorted by namespace and name, followed by a bookmark marking the end of
+// the initial events. The caller must hold the lock.
+func (s *MemoryStore) initialEvents(res schema.GroupVersionResource, matcher *listMatcher) []watch.Event {
+	var objects []*unstructured.Unstructured
+	for _, v := range s.data[res] {
+		if matcher.matches(v) {
+			objects = append(objects, v)
+		}
 	}
 
-	// check namespace
-	if n := options.Namespace; n != "" && n != accessor.GetNamespace() {
-		return false
+	sortObjects(objects)
+
+	return initialEventList(res, objects, s.history.resourceVersion(res))
+}
 
+// matchEvent filters an event using a list matcher. Modifications that
+// move an object in or out of the matched set are converted to added and
+// deleted events.
+func (s *MemoryStore) matchEvent(e event, matcher *listMatcher) (watch.Event, bool) {
+	isMatch := matcher.matches(e.Object)
+
+	if e.Type != watch.Modified || e.Old == nil {
+		return e.Event, isMatch
 	}
 
-	return true
+	wasMatch := matcher.matches(e.Old)
+
+	switch {
+	case isMatch && wasMatch:
+		return e.Event, true
+	case isMatch:
+		return watch.Event{Type: watch.Added, Object: e.Object}, true
+	case wasMatch:
+		return watch.Event{Type: watch.Deleted, Object: e.Object}, true
+	default:
+		return watch.Event{}, false
+	}
 }
 
 func (s *MemoryStore) key(u *unstructured.Unstructured) storeKey {
+	return storeKeyFor(u)
+}
+
+func storeKeyFor(u *unstructured.Unstructured) storeKey {
 	return storeKey{
 		name:      u.GetName(),
 		namespace: u.GetNamespace(),
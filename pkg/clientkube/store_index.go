@@ -0,0 +1,224 @@
+package clientkube
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/bryanl/clientkube/pkg/cluster"
+)
+
+// storeIndex maps indexed values to the keys of the objects that have them.
+type storeIndex struct {
+	indexFunc cluster.IndexFunc
+	values    map[string]map[storeKey]bool
+	// keys are the values each object is indexed by, so they can be removed
+	// when the object changes.
+	keys map[storeKey][]string
+}
+
+func newStoreIndex(indexFunc cluster.IndexFunc) *storeIndex {
+	return &storeIndex{
+		indexFunc: indexFunc,
+		values:    map[string]map[storeKey]bool{},
+		keys:      map[storeKey][]string{},
+	}
+}
+
+// update re-indexes the object stored at key. A nil object removes the key
+// from the index.
+func (i *storeIndex) update(key storeKey, u *unstructured.Unstructured) error {
+	for _, value := range i.keys[key] {
+		delete(i.values[value], key)
+		if len(i.values[value]) == 0 {
+			delete(i.values, value)
+		}
+	}
+	delete(i.keys, key)
+
+	if u == nil {
+		return nil
+	}
+
+	values, err := i.indexFunc(u)
+	if err != nil {
+		return err
+	}
+
+	for _, value := range values {
+		keys, ok := i.values[value]
+		if !ok {
+			keys = map[storeKey]bool{}
+			i.values[value] = keys
+		}
+		keys[key] = true
+	}
+
+	if len(values) > 0 {
+		i.keys[key] = values
+	}
+
+	return nil
+}
+
+// Get gets an object by namespace and name. The object is a copy, so it can
+// be modified by the caller.
+func (s *MemoryStore) Get(res schema.GroupVersionResource, namespace, name string) (*unstructured.Unstructured, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	u, ok := s.data[res][storeKey{name: name, namespace: namespace}]
+	if !ok {
+		return nil, false
+	}
+
+	return u.DeepCopy(), true
+}
+
+// GetByUID gets an object by UID from any resource. An object served by
+// more than one group/version, like a deployment in apps/v1 and
+// extensions/v1beta1, is returned from the resource that sorts first.
+func (s *MemoryStore) GetByUID(uid types.UID) (schema.GroupVersionResource, *unstructured.Unstructured, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var found schema.GroupVersionResource
+	var object *unstructured.Unstructured
+
+	for res, key := range s.uids[uid] {
+		if object != nil && found.String() < res.String() {
+			continue
+		}
+
+		found = res
+		object = s.data[res][key]
+	}
+
+	if object == nil {
+		return schema.GroupVersionResource{}, nil, false
+	}
+
+	return found, object.DeepCopy(), true
+}
+
+// AddIndexer adds an index to a resource. Objects already in the store are
+// indexed when it is added, and the index is kept up to date as objects are
+// added, updated, and deleted. Index names are unique per resource.
+func (s *MemoryStore) AddIndexer(res schema.GroupVersionResource, indexName string, indexFunc cluster.IndexFunc) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	indexes, ok := s.indexes[res]
+	if !ok {
+		indexes = map[string]*storeIndex{}
+		s.indexes[res] = indexes
+	}
+
+	if _, ok := indexes[indexName]; ok {
+		return fmt.Errorf("index %q for %s already exists", indexName, res)
+	}
+
+	index := newStoreIndex(indexFunc)
+	for key, u := range s.data[res] {
+		if err := index.update(key, u); err != nil {
+			return fmt.Errorf("index %s/%s: %w", key.namespace, key.name, err)
+		}
+	}
+
+	indexes[indexName] = index
+
+	return nil
+}
+
+// ByIndex lists the objects whose values for an index include value. The
+// objects are copies sorted by namespace and name.
+func (s *MemoryStore) ByIndex(res schema.GroupVersionResource, indexName, value string) ([]*unstructured.Unstructured, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	index, ok := s.indexes[res][indexName]
+	if !ok {
+		return nil, fmt.Errorf("index %q for %s does not exist", indexName, res)
+	}
+
+	var objects []*unstructured.Unstructured
+	for key := range index.values[value] {
+		objects = append(objects, s.data[res][key].DeepCopy())
+	}
+
+	sortObjects(objects)
+
+	return objects, nil
+}
+
+// updateIndexes re-indexes the object stored at key, replacing old, in the
+// UID index and every index of a resource. A nil object removes the key.
+// The caller must hold the lock.
+func (s *MemoryStore) updateIndexes(res schema.GroupVersionResource, key storeKey, old, u *unstructured.Unstructured) {
+	if old != nil {
+		if resources := s.uids[old.GetUID()]; resources != nil && resources[res] == key {
+			delete(resources, res)
+			if len(resources) == 0 {
+				delete(s.uids, old.GetUID())
+			}
+		}
+	}
+
+	if u != nil && u.GetUID() != "" {
+		resources, ok := s.uids[u.GetUID()]
+		if !ok {
+			resources = map[schema.GroupVersionResource]storeKey{}
+			s.uids[u.GetUID()] = resources
+		}
+		resources[res] = key
+	}
+
+	for indexName, index := range s.indexes[res] {
+		if err := index.update(key, u); err != nil {
+			s.logger.Error(err, "unable to index object",
+				"res", res,
+				"index", indexName,
+				"namespace", key.namespace,
+				"name", key.name)
+		}
+	}
+}
+
+// IndexByNodeName indexes pods by spec.nodeName.
+func IndexByNodeName(object *unstructured.Unstructured) ([]string, error) {
+	nodeName, _, err := unstructured.NestedString(object.Object, "spec", "nodeName")
+	if err != nil {
+		return nil, err
+	}
+
+	if nodeName == "" {
+		return nil, nil
+	}
+
+	return []string{nodeName}, nil
+}
+
+// IndexByOwnerUID indexes objects by the UIDs of their owners.
+func IndexByOwnerUID(object *unstructured.Unstructured) ([]string, error) {
+	var values []string
+	for _, ref := range object.GetOwnerReferences() {
+		values = append(values, string(ref.UID))
+	}
+
+	return values, nil
+}
+
+// IndexByLabel creates an index function that indexes objects by the value
+// of a label. Objects without the label aren't indexed.
+func IndexByLabel(key string) cluster.IndexFunc {
+	return func(object *unstructured.Unstructured) ([]string, error) {
+		value, ok := object.GetLabels()[key]
+		if !ok {
+			return nil, nil
+		}
+
+		return []string{value}, nil
+	}
+}
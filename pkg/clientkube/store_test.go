@@ -1,13 +1,20 @@
 package clientkube
 
 import (
+	"fmt"
+	goruntime "runtime"
+	"strconv"
 	"testing"
+	"time"
 
 	logrTesting "github.com/go-logr/logr/testing"
 	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/watch"
 
 	"github.com/bryanl/clientkube/pkg/cluster"
@@ -210,6 +217,51 @@ func TestMemoryStore_List(t *testing.T) {
 		},
 	}
 
+	podRes := schema.GroupVersionResource{
+		Version:  "v1",
+		Resource: "pods",
+	}
+
+	pod1 := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Pod",
+			"metadata": map[string]interface{}{
+				"name":      "pod1",
+				"namespace": "default",
+				"labels": map[string]interface{}{
+					"app": "web",
+				},
+			},
+			"spec": map[string]interface{}{
+				"nodeName": "node1",
+			},
+		},
+	}
+	pod2 := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Pod",
+			"metadata": map[string]interface{}{
+				"name":      "pod2",
+				"namespace": "default",
+				"labels": map[string]interface{}{
+					"app": "db",
+				},
+			},
+			"spec": map[string]interface{}{
+				"nodeName": "node2",
+			},
+		},
+	}
+
+	podData := memoryStoreData{
+		podRes: memoryStoreResData{
+			storeKey{name: pod1.GetName(), namespace: pod1.GetNamespace()}: pod1,
+			storeKey{name: pod2.GetName(), namespace: pod2.GetNamespace()}: pod2,
+		},
+	}
+
 	tests := []struct {
 		name    string
 		res     schema.GroupVersionResource
@@ -260,6 +312,63 @@ func TestMemoryStore_List(t *testing.T) {
 			},
 			wanted: &unstructured.UnstructuredList{},
 		},
+		{
+			name: "list resource with label selector",
+			res:  podRes,
+			options: cluster.ListOptions{
+				ListOptions: metav1.ListOptions{LabelSelector: "app in (web)"},
+			},
+			data: podData,
+			wanted: &unstructured.UnstructuredList{
+				Items: []unstructured.Unstructured{
+					*pod1,
+				},
+			},
+		},
+		{
+			name: "list resource with field selector",
+			res:  podRes,
+			options: cluster.ListOptions{
+				ListOptions: metav1.ListOptions{FieldSelector: "spec.nodeName=node2"},
+			},
+			data: podData,
+			wanted: &unstructured.UnstructuredList{
+				Items: []unstructured.Unstructured{
+					*pod2,
+				},
+			},
+		},
+		{
+			name: "list resource with metadata field selector",
+			res:  podRes,
+			options: cluster.ListOptions{
+				ListOptions: metav1.ListOptions{FieldSelector: "metadata.name!=pod2"},
+			},
+			data: podData,
+			wanted: &unstructured.UnstructuredList{
+				Items: []unstructured.Unstructured{
+					*pod1,
+				},
+			},
+		},
+		{
+			name: "list resource with invalid label selector",
+			res:  podRes,
+			options: cluster.ListOptions{
+				ListOptions: metav1.ListOptions{LabelSelector: "app in (web"},
+			},
+			data:    podData,
+			wantErr: true,
+		},
+		{
+			name: "list resource with unsupported field selector",
+			res:  res1,
+			options: cluster.ListOptions{
+				ListOptions: metav1.ListOptions{FieldSelector: "spec.nodeName=node1"},
+			},
+			data:    podData,
+			wantErr: true,
+		},
 	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
@@ -326,6 +435,9 @@ func TestMemoryStore_Watch(t *testing.T) {
 		},
 	}
 
+	labeledObject1 := object1.DeepCopy()
+	labeledObject1.SetLabels(map[string]string{"app": "web"})
+
 	tests := []struct {
 		name    string
 		ops     []storeOp
@@ -395,6 +507,49 @@ func TestMemoryStore_Watch(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "watch objects moving in and out of a label selector",
+			ops: []storeOp{
+				{
+					eventType: watch.Modified,
+					object:    object1,
+					res:       res1,
+				},
+				{
+					eventType: watch.Modified,
+					object:    labeledObject1,
+					res:       res1,
+				},
+				{
+					eventType: watch.Modified,
+					object:    labeledObject1,
+					res:       res1,
+				},
+				{
+					eventType: watch.Modified,
+					object:    object1,
+					res:       res1,
+				},
+			},
+			res: res1,
+			options: cluster.ListOptions{
+				ListOptions: metav1.ListOptions{LabelSelector: "app=web"},
+			},
+			wanted: []watch.Event{
+				{
+					Type:   watch.Added,
+					Object: labeledObject1,
+				},
+				{
+					Type:   watch.Modified,
+					Object: labeledObject1,
+				},
+				{
+					Type:   watch.Deleted,
+					Object: object1,
+				},
+			},
+		},
 	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
@@ -406,10 +561,14 @@ func TestMemoryStore_Watch(t *testing.T) {
 			var actual []watch.Event
 
 			done := make(chan bool, 1)
+			received := make(chan bool, 1)
 
 			go func() {
 				for e := range w.ResultChan() {
 					actual = append(actual, e)
+					if len(actual) == len(test.wanted) {
+						received <- true
+					}
 				}
 				done <- true
 			}()
@@ -425,6 +584,15 @@ func TestMemoryStore_Watch(t *testing.T) {
 				}
 			}
 
+			// events are delivered asynchronously.
+			if len(test.wanted) > 0 {
+				select {
+				case <-received:
+				case <-time.After(5 * time.Second):
+					t.Error("timed out waiting for events")
+				}
+			}
+
 			w.Stop()
 
 			<-done
@@ -433,3 +601,848 @@ func TestMemoryStore_Watch(t *testing.T) {
 		})
 	}
 }
+
+func TestMemoryStore_List_paginate(t *testing.T) {
+	res := schema.GroupVersionResource{
+		Group:    "group",
+		Version:  "version",
+		Resource: "resource",
+	}
+
+	ms := NewMemoryStore()
+
+	var wanted []string
+	for _, namespace := range []string{"b", "a"} {
+		for _, name := range []string{"object3", "object1", "object2"} {
+			ms.Update(res, &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"apiVersion": res.GroupVersion().String(),
+					"kind":       "Resource",
+					"metadata": map[string]interface{}{
+						"name":      name,
+						"namespace": namespace,
+					},
+				},
+			})
+		}
+	}
+
+	for _, namespace := range []string{"a", "b"} {
+		for _, name := range []string{"object1", "object2", "object3"} {
+			wanted = append(wanted, namespace+"/"+name)
+		}
+	}
+
+	var actual []string
+	var remaining []int64
+
+	options := cluster.ListOptions{ListOptions: metav1.ListOptions{Limit: 4}}
+	for {
+		list, err := ms.List(res, options)
+		require.NoError(t, err)
+
+		for _, item := range list.Items {
+			actual = append(actual, item.GetNamespace()+"/"+item.GetName())
+		}
+
+		if list.GetContinue() == "" {
+			require.Nil(t, list.GetRemainingItemCount())
+			break
+		}
+
+		remaining = append(remaining, *list.GetRemainingItemCount())
+		options.Continue = list.GetContinue()
+	}
+
+	require.Equal(t, wanted, actual)
+	require.Equal(t, []int64{2}, remaining)
+
+	t.Run("invalid continue token", func(t *testing.T) {
+		_, err := ms.List(res, cluster.ListOptions{ListOptions: metav1.ListOptions{Continue: "invalid"}})
+		require.True(t, apierrors.IsBadRequest(err))
+	})
+
+	t.Run("continue token for another resource", func(t *testing.T) {
+		token, err := encodeContinueToken(schema.GroupVersionResource{Resource: "other"}, storeKey{})
+		require.NoError(t, err)
+
+		_, err = ms.List(res, cluster.ListOptions{ListOptions: metav1.ListOptions{Continue: token}})
+		require.True(t, apierrors.IsBadRequest(err))
+	})
+
+	t.Run("invalid continue token for a resource without objects", func(t *testing.T) {
+		other := schema.GroupVersionResource{Resource: "other"}
+		_, err := ms.List(other, cluster.ListOptions{ListOptions: metav1.ListOptions{Continue: "invalid"}})
+		require.True(t, apierrors.IsBadRequest(err))
+	})
+}
+
+func TestMemoryStore_List_empty(t *testing.T) {
+	res := schema.GroupVersionResource{
+		Group:    "group",
+		Version:  "version",
+		Resource: "resource",
+	}
+
+	object := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": res.GroupVersion().String(),
+			"kind":       "Resource",
+			"metadata": map[string]interface{}{
+				"name":            "object",
+				"namespace":       "default",
+				"resourceVersion": "5",
+			},
+		},
+	}
+
+	ms := NewMemoryStore()
+	ms.Add(res, object)
+
+	deleted := object.DeepCopy()
+	deleted.SetResourceVersion("6")
+	ms.Delete(res, deleted)
+
+	// a list of a resource whose objects are all deleted can be watched
+	// from without missing events.
+	list, err := ms.List(res, cluster.ListOptions{})
+	require.NoError(t, err)
+	require.Empty(t, list.Items)
+	require.Equal(t, "6", list.GetResourceVersion())
+}
+
+func TestMemoryStore_Watch_resourceVersion(t *testing.T) {
+	res := schema.GroupVersionResource{
+		Group:    "group",
+		Version:  "version",
+		Resource: "resource",
+	}
+
+	newObject := func(name, resourceVersion string) *unstructured.Unstructured {
+		return &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"apiVersion": res.GroupVersion().String(),
+				"kind":       "Resource",
+				"metadata": map[string]interface{}{
+					"name":            name,
+					"namespace":       "default",
+					"resourceVersion": resourceVersion,
+				},
+			},
+		}
+	}
+
+	ms := NewMemoryStore(WithEventHistorySize(3))
+	for i := 1; i <= 5; i++ {
+		ms.Update(res, newObject(fmt.Sprintf("object%d", i), strconv.Itoa(i)))
+	}
+
+	list, err := ms.List(res, cluster.ListOptions{})
+	require.NoError(t, err)
+	require.Equal(t, "5", list.GetResourceVersion())
+
+	tests := []struct {
+		name            string
+		resourceVersion string
+		wanted          []string
+		wantExpired     bool
+	}{
+		{
+			name:            "replay events newer than resource version",
+			resourceVersion: "3",
+			wanted:          []string{"4", "5", "6"},
+		},
+		{
+			name:            "replay all buffered events",
+			resourceVersion: "2",
+			wanted:          []string{"3", "4", "5", "6"},
+		},
+		{
+			name:            "current resource version",
+			resourceVersion: "5",
+			wanted:          []string{"6"},
+		},
+		{
+			name:            "no resource version",
+			resourceVersion: "",
+			wanted:          []string{"6"},
+		},
+		{
+			name:            "resource version is too old",
+			resourceVersion: "1",
+			wantExpired:     true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ms := NewMemoryStore(WithEventHistorySize(3))
+			for i := 1; i <= 5; i++ {
+				ms.Update(res, newObject(fmt.Sprintf("object%d", i), strconv.Itoa(i)))
+			}
+
+			options := cluster.ListOptions{
+				ListOptions: metav1.ListOptions{ResourceVersion: test.resourceVersion},
+			}
+
+			w, err := ms.Watch(res, options)
+			if test.wantExpired {
+				require.True(t, apierrors.IsResourceExpired(err))
+				return
+			}
+			require.NoError(t, err)
+
+			go ms.Update(res, newObject("object6", "6"))
+
+			var actual []string
+			for e := range w.ResultChan() {
+				u := e.Object.(*unstructured.Unstructured)
+				actual = append(actual, u.GetResourceVersion())
+				if u.GetResourceVersion() == "6" {
+					w.Stop()
+				}
+			}
+
+			require.Equal(t, test.wanted, actual)
+		})
+	}
+}
+
+func TestMemoryStore_Watch_sendInitialEvents(t *testing.T) {
+	res := schema.GroupVersionResource{
+		Group:    "group",
+		Version:  "version",
+		Resource: "resource",
+	}
+
+	newObject := func(namespace, name, resourceVersion string) *unstructured.Unstructured {
+		return &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"apiVersion": res.GroupVersion().String(),
+				"kind":       "Resource",
+				"metadata": map[string]interface{}{
+					"name":            name,
+					"namespace":       namespace,
+					"resourceVersion": resourceVersion,
+				},
+			},
+		}
+	}
+
+	ms := NewMemoryStore()
+	ms.Update(res, newObject("default", "b", "1"))
+	ms.Update(res, newObject("default", "a", "2"))
+	ms.Update(res, newObject("other", "c", "3"))
+
+	w, err := ms.Watch(res, cluster.ListOptions{
+		Namespace:         "default",
+		SendInitialEvents: true,
+	})
+	require.NoError(t, err)
+
+	go ms.Update(res, newObject("default", "d", "4"))
+
+	var actual []string
+	for e := range w.ResultChan() {
+		u := e.Object.(*unstructured.Unstructured)
+
+		switch e.Type {
+		case watch.Bookmark:
+			require.Equal(t, "true", u.GetAnnotations()[cluster.InitialEventsEndAnnotation])
+			require.Equal(t, "Resource", u.GetKind())
+			actual = append(actual, "bookmark@"+u.GetResourceVersion())
+		default:
+			actual = append(actual, string(e.Type)+":"+u.GetName())
+		}
+
+		if u.GetName() == "d" {
+			w.Stop()
+		}
+	}
+
+	require.Equal(t, []string{"ADDED:a", "ADDED:b", "bookmark@3", "MODIFIED:d"}, actual)
+}
+
+func TestMemoryStore_Watch_overflow(t *testing.T) {
+	res := schema.GroupVersionResource{
+		Group:    "group",
+		Version:  "version",
+		Resource: "resource",
+	}
+
+	newObject := func(i int) *unstructured.Unstructured {
+		return &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"apiVersion": res.GroupVersion().String(),
+				"kind":       "Resource",
+				"metadata": map[string]interface{}{
+					"name":            fmt.Sprintf("object%d", i),
+					"namespace":       "default",
+					"resourceVersion": strconv.Itoa(i),
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		name         string
+		options      []Option
+		wantOverflow bool
+	}{
+		{
+			name:         "terminate",
+			options:      []Option{WithWatchQueueSize(2)},
+			wantOverflow: true,
+		},
+		{
+			name:         "block until timeout",
+			options:      []Option{WithWatchQueueSize(2), WithOverflowPolicy(OverflowBlock, 10*time.Millisecond)},
+			wantOverflow: true,
+		},
+		{
+			name:    "block while reader catches up",
+			options: []Option{WithWatchQueueSize(2), WithOverflowPolicy(OverflowBlock, 5*time.Second)},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ms := NewMemoryStore(test.options...)
+
+			w, err := ms.Watch(res, cluster.ListOptions{})
+			require.NoError(t, err)
+			defer w.Stop()
+
+			// writes don't wait for a watcher that isn't reading.
+			written := make(chan bool)
+			go func() {
+				for i := 1; i <= 5; i++ {
+					ms.Update(res, newObject(i))
+				}
+				close(written)
+			}()
+
+			if test.wantOverflow {
+				<-written
+			}
+
+			var got []string
+			for e := range w.ResultChan() {
+				if e.Type == watch.Error {
+					require.True(t, apierrors.IsResourceExpired(apierrors.FromObject(e.Object)))
+					got = append(got, "error")
+					continue
+				}
+
+				got = append(got, e.Object.(*unstructured.Unstructured).GetResourceVersion())
+				if len(got) == 5 {
+					break
+				}
+			}
+
+			stats := ms.Stats()
+			if test.wantOverflow {
+				// two events are queued, and the watcher may have taken one
+				// more before the writes.
+				require.Contains(t, [][]string{
+					{"1", "2", "error"},
+					{"1", "2", "3", "error"},
+				}, got)
+				require.Equal(t, uint64(1), stats.Overflows)
+				require.Empty(t, stats.Watchers)
+				return
+			}
+
+			<-written
+			require.Equal(t, []string{"1", "2", "3", "4", "5"}, got)
+			require.Equal(t, uint64(0), stats.Overflows)
+			require.Equal(t, []WatcherStats{{Resource: res, QueueCapacity: 2}}, stats.Watchers)
+		})
+	}
+}
+
+func TestMemoryStore_Watch_blockedWriter(t *testing.T) {
+	res := schema.GroupVersionResource{
+		Group:    "group",
+		Version:  "version",
+		Resource: "resource",
+	}
+
+	newObject := func(i int) *unstructured.Unstructured {
+		return &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"apiVersion": res.GroupVersion().String(),
+				"kind":       "Resource",
+				"metadata": map[string]interface{}{
+					"name":            fmt.Sprintf("object%d", i),
+					"namespace":       "default",
+					"resourceVersion": strconv.Itoa(i),
+				},
+			},
+		}
+	}
+
+	ms := NewMemoryStore(WithWatchQueueSize(1), WithOverflowPolicy(OverflowBlock, time.Minute))
+
+	w, err := ms.Watch(res, cluster.ListOptions{})
+	require.NoError(t, err)
+
+	// the watcher isn't read, so a write blocks once its queue is full.
+	written := make(chan bool)
+	go func() {
+		for i := 1; i <= 3; i++ {
+			ms.Update(res, newObject(i))
+		}
+		close(written)
+	}()
+
+	require.Eventually(t, func() bool {
+		_, ok := ms.Get(res, "default", "object3")
+		return ok
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// reads go through while the writer is blocked.
+	list, err := ms.List(res, cluster.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, list.Items, 3)
+	require.Len(t, ms.Stats().Watchers, 1)
+
+	select {
+	case <-written:
+		t.Fatal("writer wasn't blocked")
+	default:
+	}
+
+	// the writer stops waiting once the watcher is stopped.
+	w.Stop()
+
+	select {
+	case <-written:
+	case <-time.After(5 * time.Second):
+		t.Fatal("writer is still blocked")
+	}
+
+	require.Equal(t, uint64(0), ms.Stats().Overflows)
+}
+
+func TestMemoryStore_Watch_stop(t *testing.T) {
+	res := schema.GroupVersionResource{
+		Group:    "group",
+		Version:  "version",
+		Resource: "resource",
+	}
+
+	object := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": res.GroupVersion().String(),
+			"kind":       "Resource",
+			"metadata": map[string]interface{}{
+				"name":            "object",
+				"namespace":       "default",
+				"resourceVersion": "1",
+			},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		options cluster.ListOptions
+		run     func(ms *MemoryStore, w cluster.Watch)
+	}{
+		{
+			name: "stop an idle watcher",
+			run: func(ms *MemoryStore, w cluster.Watch) {
+				w.Stop()
+			},
+		},
+		{
+			name: "stop more than once",
+			run: func(ms *MemoryStore, w cluster.Watch) {
+				w.Stop()
+				w.Stop()
+			},
+		},
+		{
+			name: "stop while a send is blocked",
+			run: func(ms *MemoryStore, w cluster.Watch) {
+				ms.Update(res, object)
+				ms.Update(res, object)
+				w.Stop()
+			},
+		},
+		{
+			name:    "stop while replaying",
+			options: cluster.ListOptions{SendInitialEvents: true},
+			run: func(ms *MemoryStore, w cluster.Watch) {
+				w.Stop()
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			before := goruntime.NumGoroutine()
+
+			ms := NewMemoryStore()
+			ms.Update(res, object)
+
+			w, err := ms.Watch(res, test.options)
+			require.NoError(t, err)
+
+			stopped := make(chan bool)
+			go func() {
+				test.run(ms, w)
+				close(stopped)
+			}()
+
+			select {
+			case <-stopped:
+			case <-time.After(5 * time.Second):
+				t.Fatal("stop blocked")
+			}
+
+			// the result channel is closed without being read.
+			require.Eventually(t, func() bool {
+				for {
+					select {
+					case _, ok := <-w.ResultChan():
+						if !ok {
+							return true
+						}
+					default:
+						return false
+					}
+				}
+			}, 5*time.Second, 10*time.Millisecond)
+
+			require.Empty(t, ms.Stats().Watchers)
+
+			// require.Eventually runs its condition in a goroutine, so the
+			// goroutines are counted here.
+			deadline := time.Now().Add(5 * time.Second)
+			for goruntime.NumGoroutine() > before && time.Now().Before(deadline) {
+				time.Sleep(10 * time.Millisecond)
+			}
+			require.LessOrEqual(t, goruntime.NumGoroutine(), before)
+		})
+	}
+}
+
+func TestMemoryStore_Get(t *testing.T) {
+	res := schema.GroupVersionResource{
+		Group:    "group",
+		Version:  "version",
+		Resource: "resource",
+	}
+
+	object := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": res.GroupVersion().String(),
+			"kind":       "Resource",
+			"metadata": map[string]interface{}{
+				"name":      "object",
+				"namespace": "default",
+			},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		res        schema.GroupVersionResource
+		namespace  string
+		objectName string
+		wanted     *unstructured.Unstructured
+	}{
+		{
+			name:       "object exists",
+			res:        res,
+			namespace:  "default",
+			objectName: "object",
+			wanted:     object,
+		},
+		{
+			name:       "object in another namespace",
+			res:        res,
+			namespace:  "other",
+			objectName: "object",
+		},
+		{
+			name:       "object does not exist",
+			res:        res,
+			namespace:  "default",
+			objectName: "missing",
+		},
+		{
+			name:       "resource does not exist",
+			res:        schema.GroupVersionResource{Version: "v1", Resource: "missing"},
+			namespace:  "default",
+			objectName: "object",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ms := NewMemoryStore()
+			ms.Add(res, object)
+
+			actual, ok := ms.Get(test.res, test.namespace, test.objectName)
+			if test.wanted == nil {
+				require.False(t, ok)
+				return
+			}
+
+			require.True(t, ok)
+			require.Equal(t, test.wanted, actual)
+
+			// the object is a copy.
+			actual.SetName("changed")
+			require.Equal(t, "object", object.GetName())
+		})
+	}
+}
+
+func TestMemoryStore_ByIndex(t *testing.T) {
+	res := schema.GroupVersionResource{
+		Version:  "v1",
+		Resource: "pods",
+	}
+
+	newPod := func(name, nodeName string, labels map[string]string, ownerUIDs ...string) *unstructured.Unstructured {
+		u := &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "Pod",
+				"metadata": map[string]interface{}{
+					"name":      name,
+					"namespace": "default",
+				},
+				"spec": map[string]interface{}{
+					"nodeName": nodeName,
+				},
+			},
+		}
+		u.SetLabels(labels)
+
+		var refs []metav1.OwnerReference
+		for _, uid := range ownerUIDs {
+			refs = append(refs, metav1.OwnerReference{
+				APIVersion: "apps/v1",
+				Kind:       "ReplicaSet",
+				Name:       "rs-" + uid,
+				UID:        types.UID(uid),
+			})
+		}
+		u.SetOwnerReferences(refs)
+
+		return u
+	}
+
+	pod1 := newPod("pod1", "node1", map[string]string{"app": "web"}, "uid1")
+	pod2 := newPod("pod2", "node1", map[string]string{"app": "db"}, "uid2")
+	pod3 := newPod("pod3", "node2", nil, "uid1")
+
+	names := func(objects []*unstructured.Unstructured) []string {
+		var list []string
+		for _, object := range objects {
+			list = append(list, object.GetName())
+		}
+		return list
+	}
+
+	tests := []struct {
+		name      string
+		indexName string
+		indexFunc cluster.IndexFunc
+		update    func(ms *MemoryStore)
+		value     string
+		wanted    []string
+	}{
+		{
+			name:      "by node name",
+			indexName: "nodeName",
+			indexFunc: IndexByNodeName,
+			value:     "node1",
+			wanted:    []string{"pod1", "pod2"},
+		},
+		{
+			name:      "by owner UID",
+			indexName: "owner",
+			indexFunc: IndexByOwnerUID,
+			value:     "uid1",
+			wanted:    []string{"pod1", "pod3"},
+		},
+		{
+			name:      "by label",
+			indexName: "app",
+			indexFunc: IndexByLabel("app"),
+			value:     "web",
+			wanted:    []string{"pod1"},
+		},
+		{
+			name:      "value that is not indexed",
+			indexName: "nodeName",
+			indexFunc: IndexByNodeName,
+			value:     "node3",
+		},
+		{
+			name:      "object added after the index",
+			indexName: "nodeName",
+			indexFunc: IndexByNodeName,
+			update: func(ms *MemoryStore) {
+				ms.Add(res, newPod("pod4", "node2", nil))
+			},
+			value:  "node2",
+			wanted: []string{"pod3", "pod4"},
+		},
+		{
+			name:      "object updated after the index",
+			indexName: "nodeName",
+			indexFunc: IndexByNodeName,
+			update: func(ms *MemoryStore) {
+				ms.Update(res, newPod("pod2", "node2", nil))
+			},
+			value:  "node1",
+			wanted: []string{"pod1"},
+		},
+		{
+			name:      "object deleted after the index",
+			indexName: "owner",
+			indexFunc: IndexByOwnerUID,
+			update: func(ms *MemoryStore) {
+				ms.Delete(res, pod1)
+			},
+			value:  "uid1",
+			wanted: []string{"pod3"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ms := NewMemoryStore()
+			ms.Add(res, pod1)
+			ms.Add(res, pod2)
+			ms.Add(res, pod3)
+
+			require.NoError(t, ms.AddIndexer(res, test.indexName, test.indexFunc))
+
+			if test.update != nil {
+				test.update(ms)
+			}
+
+			actual, err := ms.ByIndex(res, test.indexName, test.value)
+			require.NoError(t, err)
+			require.Equal(t, test.wanted, names(actual))
+		})
+	}
+}
+
+func TestMemoryStore_AddIndexer_errors(t *testing.T) {
+	res := schema.GroupVersionResource{
+		Version:  "v1",
+		Resource: "pods",
+	}
+
+	ms := NewMemoryStore()
+	require.NoError(t, ms.AddIndexer(res, "nodeName", IndexByNodeName))
+	require.Error(t, ms.AddIndexer(res, "nodeName", IndexByNodeName))
+
+	_, err := ms.ByIndex(res, "missing", "value")
+	require.Error(t, err)
+}
+
+func TestMemoryStore_GetByUID(t *testing.T) {
+	res1 := schema.GroupVersionResource{
+		Group:    "apps",
+		Version:  "v1",
+		Resource: "deployments",
+	}
+	res2 := schema.GroupVersionResource{
+		Group:    "extensions",
+		Version:  "v1beta1",
+		Resource: "deployments",
+	}
+
+	newObject := func(res schema.GroupVersionResource, name string, uid types.UID) *unstructured.Unstructured {
+		u := &unstructured.Unstructured{}
+		u.SetAPIVersion(res.GroupVersion().String())
+		u.SetKind("Deployment")
+		u.SetNamespace("default")
+		u.SetName(name)
+		u.SetUID(uid)
+		return u
+	}
+
+	tests := []struct {
+		name      string
+		update    func(ms *MemoryStore)
+		uid       types.UID
+		wantedRes schema.GroupVersionResource
+		wanted    string
+	}{
+		{
+			name: "object exists",
+			update: func(ms *MemoryStore) {
+				ms.Add(res1, newObject(res1, "object", "uid1"))
+			},
+			uid:       "uid1",
+			wantedRes: res1,
+			wanted:    "object",
+		},
+		{
+			name: "object does not exist",
+			update: func(ms *MemoryStore) {
+				ms.Add(res1, newObject(res1, "object", "uid1"))
+			},
+			uid: "uid2",
+		},
+		{
+			name: "object deleted",
+			update: func(ms *MemoryStore) {
+				ms.Add(res1, newObject(res1, "object", "uid1"))
+				ms.Delete(res1, newObject(res1, "object", "uid1"))
+			},
+			uid: "uid1",
+		},
+		{
+			name: "object recreated with a new UID",
+			update: func(ms *MemoryStore) {
+				ms.Add(res1, newObject(res1, "object", "uid1"))
+				ms.Update(res1, newObject(res1, "object", "uid2"))
+			},
+			uid: "uid1",
+		},
+		{
+			name: "object in more than one group",
+			update: func(ms *MemoryStore) {
+				ms.Add(res2, newObject(res2, "object", "uid1"))
+				ms.Add(res1, newObject(res1, "object", "uid1"))
+			},
+			uid:       "uid1",
+			wantedRes: res1,
+			wanted:    "object",
+		},
+		{
+			name: "object deleted from one of its groups",
+			update: func(ms *MemoryStore) {
+				ms.Add(res1, newObject(res1, "object", "uid1"))
+				ms.Add(res2, newObject(res2, "object", "uid1"))
+				ms.Delete(res1, newObject(res1, "object", "uid1"))
+			},
+			uid:       "uid1",
+			wantedRes: res2,
+			wanted:    "object",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ms := NewMemoryStore()
+			test.update(ms)
+
+			res, actual, ok := ms.GetByUID(test.uid)
+			if test.wanted == "" {
+				require.False(t, ok)
+				return
+			}
+
+			require.True(t, ok)
+			require.Equal(t, test.wantedRes, res)
+			require.Equal(t, test.wanted, actual.GetName())
+			require.Equal(t, test.uid, actual.GetUID())
+		})
+	}
+}
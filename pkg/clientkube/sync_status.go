@@ -0,0 +1,168 @@
+package clientkube
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/bryanl/clientkube/pkg/cluster"
+)
+
+// SyncState is the state of a resource in an informer.
+type SyncState string
+
+const (
+	// SyncStatePending means the resource is waiting to be listed.
+	SyncStatePending SyncState = "Pending"
+	// SyncStateSyncing means the resource is being listed.
+	SyncStateSyncing SyncState = "Syncing"
+	// SyncStateSynced means the resource is served from the store.
+	SyncStateSynced SyncState = "Synced"
+	// SyncStateFailed means the resource could not be listed or watched.
+	// Unless the failure is permanent, the resource is retried.
+	SyncStateFailed SyncState = "Failed"
+)
+
+// ResourceSyncStatus is the sync status of a resource.
+type ResourceSyncStatus struct {
+	// Resource is the group/version/resource.
+	Resource schema.GroupVersionResource
+	// State is the sync state.
+	State SyncState
+	// Err is the error that caused the resource to fail.
+	Err error
+	// Permanent is true if a failed resource will not be retried, such as
+	// when listing it is forbidden.
+	Permanent bool
+	// ObjectCount is the number of objects in the store for a synced
+	// resource.
+	ObjectCount int
+}
+
+type syncState struct {
+	state SyncState
+	err   error
+}
+
+// setSyncState sets the sync state of a resource and wakes up anything
+// waiting for a change. The caller must hold the lock.
+func (inf *MemoryStoreInformer) setSyncState(res schema.GroupVersionResource, state SyncState, err error) {
+	inf.syncStates[res] = syncState{state: state, err: err}
+
+	if state == SyncStateSynced {
+		inf.synced[res] = true
+	} else {
+		delete(inf.synced, res)
+	}
+
+	close(inf.syncChanged)
+	inf.syncChanged = make(chan struct{})
+}
+
+// HasSynced returns true if a resource is synced and served from the store.
+func (inf *MemoryStoreInformer) HasSynced(res schema.GroupVersionResource) bool {
+	inf.mu.RLock()
+	defer inf.mu.RUnlock()
+
+	return inf.isResourceSynced(res)
+}
+
+// WaitForSync waits until resources are synced. If no resources are given,
+// it waits for every resource the informer knows about that has not failed
+// permanently. It returns an error if a given resource fails permanently,
+// is unknown to the informer, or ctx is done. Resources that failed with
+// other errors are waited on while they are retried. A lazy informer starts
+// informing the given resources.
+func (inf *MemoryStoreInformer) WaitForSync(ctx context.Context, resources ...schema.GroupVersionResource) error {
+	for _, res := range resources {
+		inf.touch(res)
+	}
+
+	for {
+		inf.mu.RLock()
+		changed := inf.syncChanged
+		done, err := inf.checkSynced(resources)
+		inf.mu.RUnlock()
+
+		if err != nil || done {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("wait for sync: %w", ctx.Err())
+		case <-changed:
+		}
+	}
+}
+
+// checkSynced returns true if resources are synced. The caller must hold
+// the lock.
+func (inf *MemoryStoreInformer) checkSynced(resources []schema.GroupVersionResource) (bool, error) {
+	if len(resources) == 0 {
+		for res, s := range inf.syncStates {
+			if s.state == SyncStateFailed && isPermanentError(s.err) {
+				continue
+			}
+
+			resources = append(resources, res)
+		}
+	}
+
+	done := true
+
+	for _, res := range resources {
+		s, ok := inf.syncStates[res]
+		if !ok {
+			return false, fmt.Errorf("resource %s is not informed", res)
+		}
+
+		switch s.state {
+		case SyncStateFailed:
+			if isPermanentError(s.err) {
+				return false, fmt.Errorf("resource %s failed to sync: %w", res, s.err)
+			}
+
+			done = false
+		case SyncStateSynced:
+		default:
+			done = false
+		}
+	}
+
+	return done, nil
+}
+
+// SyncStatus returns the sync status of each resource the informer knows
+// about, sorted by resource.
+func (inf *MemoryStoreInformer) SyncStatus() []ResourceSyncStatus {
+	inf.mu.RLock()
+	defer inf.mu.RUnlock()
+
+	var list []ResourceSyncStatus
+
+	for res, s := range inf.syncStates {
+		status := ResourceSyncStatus{
+			Resource:  res,
+			State:     s.state,
+			Err:       s.err,
+			Permanent: s.state == SyncStateFailed && isPermanentError(s.err),
+		}
+
+		if s.state == SyncStateSynced {
+			if objects, err := inf.store.List(res, cluster.ListOptions{}); err == nil {
+				status.ObjectCount = len(objects.Items)
+			}
+		}
+
+		list = append(list, status)
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Resource.String() < list[j].Resource.String()
+	})
+
+	return list
+}
@@ -0,0 +1,139 @@
+package clientkube
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/watch"
+
+	"github.com/bryanl/clientkube/pkg/cluster"
+	"github.com/bryanl/clientkube/pkg/mocks"
+)
+
+func TestMemoryStoreInformer_WaitForSync(t *testing.T) {
+	res1 := schema.GroupVersionResource{
+		Group:    "group1",
+		Version:  "version",
+		Resource: "resource",
+	}
+	res2 := schema.GroupVersionResource{
+		Group:    "group2",
+		Version:  "version",
+		Resource: "resource",
+	}
+	res3 := schema.GroupVersionResource{
+		Group:    "group3",
+		Version:  "version",
+		Resource: "resource",
+	}
+
+	apiResource := metav1.APIResource{
+		Name:       "resource",
+		Namespaced: true,
+		Kind:       "Resource",
+		Verbs:      metav1.Verbs{"list", "watch"},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	w := mocks.NewMockWatch(ctrl)
+	w.EXPECT().ResultChan().Return(make(chan watch.Event)).AnyTimes()
+	w.EXPECT().Stop().AnyTimes()
+
+	listRes1 := make(chan struct{})
+	listErr := apierrors.NewForbidden(res2.GroupResource(), "", fmt.Errorf("not allowed"))
+
+	client := mocks.NewMockClient(ctrl)
+	client.EXPECT().Resources().Return(cluster.Resources{
+		newResource(res1.GroupVersion(), apiResource),
+		newResource(res2.GroupVersion(), apiResource),
+		newResource(res3.GroupVersion(), apiResource),
+	}, nil)
+	client.EXPECT().
+		List(gomock.Any(), res1, cluster.ListOptions{}).
+		DoAndReturn(func(context.Context, schema.GroupVersionResource, cluster.ListOptions) (*unstructured.UnstructuredList, error) {
+			<-listRes1
+			return &unstructured.UnstructuredList{
+				Items: []unstructured.Unstructured{
+					{
+						Object: map[string]interface{}{
+							"apiVersion": res1.GroupVersion().String(),
+							"kind":       "Resource",
+							"metadata": map[string]interface{}{
+								"name":      "object",
+								"namespace": "default",
+							},
+						},
+					},
+				},
+			}, nil
+		})
+	client.EXPECT().Watch(gomock.Any(), res1, gomock.Any()).Return(w, nil)
+	client.EXPECT().List(gomock.Any(), res2, cluster.ListOptions{}).Return(nil, listErr)
+	gomock.InOrder(
+		client.EXPECT().
+			List(gomock.Any(), res3, cluster.ListOptions{}).
+			Return(nil, fmt.Errorf("transient")),
+		client.EXPECT().
+			List(gomock.Any(), res3, cluster.ListOptions{}).
+			Return(&unstructured.UnstructuredList{}, nil),
+	)
+	client.EXPECT().Watch(gomock.Any(), res3, gomock.Any()).Return(w, nil)
+
+	msi := NewInformer(client, WithAsyncStart(), WithBackoff(wait.Backoff{Duration: time.Millisecond}))
+	require.NoError(t, msi.Start(ctx))
+	defer func() {
+		require.NoError(t, msi.Stop())
+	}()
+
+	require.False(t, msi.HasSynced(res1))
+	require.Error(t, msi.WaitForSync(ctx, schema.GroupVersionResource{Resource: "unknown"}))
+
+	waitCtx, waitCancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	require.Error(t, msi.WaitForSync(waitCtx, res1))
+	waitCancel()
+
+	close(listRes1)
+
+	require.NoError(t, msi.WaitForSync(ctx, res1))
+	require.True(t, msi.HasSynced(res1))
+
+	err := msi.WaitForSync(ctx, res2)
+	require.Error(t, err)
+	require.True(t, errors.Is(err, listErr))
+
+	// the transient failure is retried.
+	require.NoError(t, msi.WaitForSync(ctx, res3))
+
+	// waiting for everything skips resources that failed permanently.
+	require.NoError(t, msi.WaitForSync(ctx))
+
+	status := msi.SyncStatus()
+	require.Len(t, status, 3)
+
+	require.Equal(t, ResourceSyncStatus{Resource: res1, State: SyncStateSynced, ObjectCount: 1}, status[0])
+	require.Equal(t, res2, status[1].Resource)
+	require.Equal(t, SyncStateFailed, status[1].State)
+	require.True(t, status[1].Permanent)
+	require.True(t, errors.Is(status[1].Err, listErr))
+	require.Equal(t, ResourceSyncStatus{Resource: res3, State: SyncStateSynced}, status[2])
+
+	// a resource that is not synced is listed from the cluster.
+	client.EXPECT().List(gomock.Any(), res2, cluster.ListOptions{}).Return(nil, listErr)
+	_, err = msi.List(ctx, res2, cluster.ListOptions{})
+	require.True(t, apierrors.IsForbidden(err))
+}
@@ -1,37 +1,182 @@
 package clientkube
 
 import (
+	"sync"
+
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/watch"
 
 	"github.com/bryanl/clientkube/pkg/cluster"
 )
 
+// UpdatableWatcher is a watch whose source can be replaced while it is
+// being watched. It is safe for concurrent use.
 type UpdatableWatcher struct {
-	source cluster.Watch
+	ch     chan watch.Event
+	swapCh chan cluster.Watch
+	done   chan struct{}
+	once   sync.Once
+
+	// onStop is called once when the watcher stops.
+	onStop func(w *UpdatableWatcher)
+
+	source          cluster.Watch
+	resourceVersion string
+	mu              sync.Mutex
 }
 
 var _ cluster.Watch = &UpdatableWatcher{}
 
+// NewUpdatableWatcher creates an UpdatableWatcher that forwards events from
+// source.
 func NewUpdatableWatcher(source cluster.Watch) *UpdatableWatcher {
+	return newUpdatableWatcher(source, nil)
+}
+
+func newUpdatableWatcher(source cluster.Watch, onStop func(w *UpdatableWatcher)) *UpdatableWatcher {
 	w := UpdatableWatcher{
+		ch:     make(chan watch.Event),
+		swapCh: make(chan cluster.Watch),
+		done:   make(chan struct{}),
+		onStop: onStop,
 		source: source,
 	}
 
+	go w.run(source)
+
 	return &w
 }
 
+// SetSource replaces the source and stops the old one. An event already
+// received from the old source is still forwarded. Events from the new
+// source that are not newer than the last received event are skipped, so
+// a source that replays from that resource version doesn't duplicate
+// events. If the watcher has stopped, source is stopped.
 func (w *UpdatableWatcher) SetSource(source cluster.Watch) {
-	w.source = source
+	select {
+	case w.swapCh <- source:
+		w.mu.Lock()
+		w.source = source
+		w.mu.Unlock()
+	case <-w.done:
+		stopWatch(source)
+	}
 }
 
+// GetSource returns the current source.
 func (w *UpdatableWatcher) GetSource() cluster.Watch {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
 	return w.source
 }
 
+// ResourceVersion returns the resource version of the last forwarded
+// event, or an empty string if none has been forwarded.
+func (w *UpdatableWatcher) ResourceVersion() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.resourceVersion
+}
+
+// Stop stops the watcher and its source. It can be called more than once.
 func (w *UpdatableWatcher) Stop() {
-	panic("implement me")
+	w.once.Do(func() {
+		close(w.done)
+
+		if w.onStop != nil {
+			w.onStop(w)
+		}
+	})
 }
 
+// ResultChan returns the forwarded events. It is closed when the watcher
+// is stopped or its source ends.
 func (w *UpdatableWatcher) ResultChan() <-chan watch.Event {
-	panic("implement me")
+	return w.ch
+}
+
+// run forwards events from the current source until the watcher is
+// stopped or the source ends.
+func (w *UpdatableWatcher) run(source cluster.Watch) {
+	defer close(w.ch)
+
+	var pending *watch.Event
+	// skipThrough is the resource version events from a new source must be
+	// newer than. It is cleared once such an event is seen.
+	var skipThrough string
+
+	for {
+		var in <-chan watch.Event
+		var out chan<- watch.Event
+		var e watch.Event
+
+		if pending == nil {
+			in = source.ResultChan()
+		} else {
+			out = w.ch
+			e = *pending
+		}
+
+		select {
+		case <-w.done:
+			stopWatch(source)
+			return
+		case next := <-w.swapCh:
+			stopWatch(source)
+			source = next
+
+			w.mu.Lock()
+			skipThrough = w.resourceVersion
+			w.mu.Unlock()
+
+			// an event received from the old source is still delivered, so
+			// the new source's replay of it is skipped.
+			if pending != nil {
+				rv := eventResourceVersion(*pending)
+				if _, ok := parseResourceVersion(rv); ok && !isResourceVersionNewer(skipThrough, rv) {
+					skipThrough = rv
+				}
+			}
+		case out <- e:
+			if rv := eventResourceVersion(e); rv != "" {
+				w.mu.Lock()
+				w.resourceVersion = rv
+				w.mu.Unlock()
+			}
+
+			pending = nil
+		case next, ok := <-in:
+			if !ok {
+				w.Stop()
+				return
+			}
+
+			if skipThrough != "" {
+				rv := eventResourceVersion(next)
+				if _, ok := parseResourceVersion(rv); ok && !isResourceVersionNewer(rv, skipThrough) {
+					continue
+				}
+
+				skipThrough = ""
+			}
+
+			pending = &next
+		}
+	}
+}
+
+// eventResourceVersion returns the resource version of an event's object.
+func eventResourceVersion(e watch.Event) string {
+	if e.Type == watch.Error {
+		return ""
+	}
+
+	accessor, err := meta.Accessor(e.Object)
+	if err != nil {
+		return ""
+	}
+
+	return accessor.GetResourceVersion()
 }
@@ -0,0 +1,186 @@
+package clientkube
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+
+	"github.com/bryanl/clientkube/pkg/cluster"
+	"github.com/bryanl/clientkube/pkg/mocks"
+)
+
+func newVersionedObject(name, resourceVersion string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "group/version",
+			"kind":       "Resource",
+			"metadata": map[string]interface{}{
+				"name":            name,
+				"namespace":       "default",
+				"resourceVersion": resourceVersion,
+			},
+		},
+	}
+}
+
+func receiveEvent(t *testing.T, w cluster.Watch) watch.Event {
+	select {
+	case e, ok := <-w.ResultChan():
+		require.True(t, ok, "result channel is closed")
+		return e
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for event")
+		return watch.Event{}
+	}
+}
+
+func TestUpdatableWatcher(t *testing.T) {
+	source1 := watch.NewFakeWithChanSize(10, false)
+	source2 := watch.NewFakeWithChanSize(10, false)
+
+	w := NewUpdatableWatcher(source1)
+
+	source1.Add(newVersionedObject("a", "1"))
+	source1.Modify(newVersionedObject("a", "2"))
+
+	e := receiveEvent(t, w)
+	require.Equal(t, watch.Added, e.Type)
+	e = receiveEvent(t, w)
+	require.Equal(t, "2", e.Object.(*unstructured.Unstructured).GetResourceVersion())
+	require.Eventually(t, func() bool {
+		return w.ResourceVersion() == "2"
+	}, time.Second, 10*time.Millisecond)
+
+	// the new source replays an event that was already forwarded.
+	source2.Modify(newVersionedObject("a", "2"))
+	source2.Modify(newVersionedObject("a", "3"))
+	w.SetSource(source2)
+	require.Equal(t, source2, w.GetSource())
+
+	e = receiveEvent(t, w)
+	require.Equal(t, "3", e.Object.(*unstructured.Unstructured).GetResourceVersion())
+
+	require.Eventually(t, source1.IsStopped, time.Second, 10*time.Millisecond)
+
+	w.Stop()
+	w.Stop()
+
+	_, ok := <-w.ResultChan()
+	require.False(t, ok)
+	require.Eventually(t, source2.IsStopped, time.Second, 10*time.Millisecond)
+
+	// a source set after the watcher stopped is stopped.
+	source3 := watch.NewFake()
+	w.SetSource(source3)
+	require.Eventually(t, source3.IsStopped, time.Second, 10*time.Millisecond)
+}
+
+func TestUpdatableWatcher_sourceEnds(t *testing.T) {
+	source := watch.NewFake()
+	w := NewUpdatableWatcher(source)
+
+	source.Stop()
+
+	_, ok := <-w.ResultChan()
+	require.False(t, ok)
+}
+
+func TestMemoryStoreInformer_Watch_switchToStore(t *testing.T) {
+	res := schema.GroupVersionResource{
+		Group:    "group",
+		Version:  "version",
+		Resource: "resource",
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	source := watch.NewFakeWithChanSize(10, false)
+
+	client := mocks.NewMockClient(ctrl)
+	client.EXPECT().Watch(gomock.Any(), res, cluster.ListOptions{}).Return(source, nil)
+
+	store := NewMemoryStore()
+	msi := NewInformer(client, WithStore(store))
+
+	w, err := msi.Watch(context.Background(), res, cluster.ListOptions{})
+	require.NoError(t, err)
+
+	source.Add(newVersionedObject("a", "5"))
+	e := receiveEvent(t, w)
+	require.Equal(t, "5", e.Object.(*unstructured.Unstructured).GetResourceVersion())
+
+	// the store catches up with the cluster, and the resource is synced.
+	store.Add(res, newVersionedObject("a", "5"))
+	store.Update(res, newVersionedObject("a", "6"))
+	require.NoError(t, msi.SetSynced(res, nil))
+
+	require.Eventually(t, source.IsStopped, time.Second, 10*time.Millisecond)
+
+	e = receiveEvent(t, w)
+	require.Equal(t, watch.Modified, e.Type)
+	require.Equal(t, "6", e.Object.(*unstructured.Unstructured).GetResourceVersion())
+
+	store.Update(res, newVersionedObject("a", "7"))
+	e = receiveEvent(t, w)
+	require.Equal(t, "7", e.Object.(*unstructured.Unstructured).GetResourceVersion())
+
+	w.Stop()
+
+	msi.mu.RLock()
+	defer msi.mu.RUnlock()
+	require.Equal(t, 0, msi.activeWatches[res])
+	require.Empty(t, msi.watchDescriptors)
+}
+
+func TestUpdatableWatcher_swapWithPendingEvent(t *testing.T) {
+	source1 := watch.NewFakeWithChanSize(10, false)
+	source2 := watch.NewFakeWithChanSize(10, false)
+	source3 := watch.NewFakeWithChanSize(10, false)
+
+	w := NewUpdatableWatcher(source1)
+
+	// waitPending waits until the watcher has received the source's events
+	// but the consumer has not read them.
+	waitPending := func(source *watch.FakeWatcher) {
+		require.Eventually(t, func() bool {
+			return len(source.ResultChan()) == 0
+		}, time.Second, 10*time.Millisecond)
+	}
+
+	// the new source doesn't replay the pending event.
+	source1.Add(newVersionedObject("a", "1"))
+	waitPending(source1)
+
+	source2.Modify(newVersionedObject("a", "2"))
+	w.SetSource(source2)
+
+	e := receiveEvent(t, w)
+	require.Equal(t, watch.Added, e.Type)
+	require.Equal(t, "1", e.Object.(*unstructured.Unstructured).GetResourceVersion())
+
+	e = receiveEvent(t, w)
+	require.Equal(t, "2", e.Object.(*unstructured.Unstructured).GetResourceVersion())
+
+	// the new source replays the pending event.
+	source2.Modify(newVersionedObject("a", "3"))
+	waitPending(source2)
+
+	source3.Modify(newVersionedObject("a", "3"))
+	source3.Modify(newVersionedObject("a", "4"))
+	w.SetSource(source3)
+
+	e = receiveEvent(t, w)
+	require.Equal(t, "3", e.Object.(*unstructured.Unstructured).GetResourceVersion())
+
+	e = receiveEvent(t, w)
+	require.Equal(t, "4", e.Object.(*unstructured.Unstructured).GetResourceVersion())
+
+	w.Stop()
+}
@@ -0,0 +1,149 @@
+package clientkube
+
+import (
+	"sort"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+// OverflowPolicy is what MemoryStore does when a watcher's queue is full.
+type OverflowPolicy int
+
+const (
+	// OverflowTerminate terminates the watcher. Its queued events are
+	// delivered, followed by a resource expired error event, so the caller
+	// can list and watch again.
+	OverflowTerminate OverflowPolicy = iota
+	// OverflowBlock blocks the writer until the queue has room. If the
+	// queue is still full after the overflow timeout, the watcher is
+	// terminated. Other writers wait for the blocked writer, so events stay
+	// in order, but reads don't.
+	OverflowBlock
+)
+
+// storeWatcher is a watcher registered with MemoryStore. Events for its
+// resource are queued until the watcher delivers them. done is closed once
+// the watcher is unregistered.
+type storeWatcher struct {
+	res   schema.GroupVersionResource
+	queue chan event
+	done  chan struct{}
+}
+
+// blockedEvent is an event that didn't fit in a watcher's queue.
+type blockedEvent struct {
+	id string
+	sw *storeWatcher
+	e  event
+}
+
+// WatcherStats describes the queue of a MemoryStore watcher.
+type WatcherStats struct {
+	// Resource is the group/version/resource being watched.
+	Resource schema.GroupVersionResource
+	// QueueDepth is the number of events waiting to be delivered.
+	QueueDepth int
+	// QueueCapacity is the size of the queue.
+	QueueCapacity int
+}
+
+// StoreStats describes the watchers of a MemoryStore.
+type StoreStats struct {
+	// Watchers are the active watchers, sorted by resource.
+	Watchers []WatcherStats
+	// Overflows is the number of watchers terminated because their queue
+	// was full.
+	Overflows uint64
+}
+
+// Stats returns the queue depths of the store's watchers.
+func (s *MemoryStore) Stats() StoreStats {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	stats := StoreStats{
+		Overflows: s.overflows,
+	}
+
+	for _, sw := range s.watchers {
+		stats.Watchers = append(stats.Watchers, WatcherStats{
+			Resource:      sw.res,
+			QueueDepth:    len(sw.queue),
+			QueueCapacity: cap(sw.queue),
+		})
+	}
+
+	sort.Slice(stats.Watchers, func(i, j int) bool {
+		return stats.Watchers[i].Resource.String() < stats.Watchers[j].Resource.String()
+	})
+
+	return stats
+}
+
+// enqueue queues an event for a watcher without blocking. It returns false
+// if the queue is full. The caller must hold the lock.
+func (s *MemoryStore) enqueue(sw *storeWatcher, e event) bool {
+	select {
+	case sw.queue <- e:
+		return true
+	default:
+		return false
+	}
+}
+
+// enqueueBlocked waits up to the overflow timeout for room in the queues
+// of the writer's blocked events, and terminates the watchers that have
+// none. The caller must hold writeMu, but not the lock, so reads continue
+// while it waits.
+func (s *MemoryStore) enqueueBlocked() {
+	blocked := s.blocked
+	s.blocked = nil
+
+	for _, b := range blocked {
+		if s.waitToEnqueue(b) {
+			continue
+		}
+
+		s.mu.Lock()
+		// the watcher may have stopped while the writer waited.
+		if s.watchers[b.id] == b.sw {
+			s.terminateWatcher(b.id, b.sw)
+		}
+		s.mu.Unlock()
+	}
+}
+
+// waitToEnqueue waits up to the overflow timeout to queue a blocked event.
+// It returns false if the queue is still full.
+func (s *MemoryStore) waitToEnqueue(b blockedEvent) bool {
+	timer := time.NewTimer(s.overflowTimeout)
+	defer timer.Stop()
+
+	select {
+	case b.sw.queue <- b.e:
+		return true
+	case <-b.sw.done:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
+
+// terminateWatcher unregisters a watcher whose queue is full, and closes
+// its queue so it sends an overflow event. The caller must hold the lock.
+func (s *MemoryStore) terminateWatcher(id string, sw *storeWatcher) {
+	s.logger.Info("watch queue is full; terminating watcher",
+		"res", sw.res, "queue-size", cap(sw.queue))
+
+	delete(s.watchers, id)
+	close(sw.queue)
+	s.overflows++
+}
+
+// overflowEvent is sent to a watcher terminated because its queue was full.
+func overflowEvent() watch.Event {
+	return errorEvent(apierrors.NewResourceExpired("watch queue is full"))
+}
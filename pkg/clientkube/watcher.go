@@ -1,16 +1,23 @@
 package clientkube
 
 import (
+	"sync"
+
 	"k8s.io/apimachinery/pkg/watch"
 )
 
+// Watcher is a watch whose events are sent by a MemoryStore. Its result
+// channel is closed once the sender sees the watch is stopped.
 type Watcher struct {
 	ch     chan watch.Event
 	stopCh chan bool
+	once   sync.Once
 }
 
 var _ watch.Interface = &Watcher{}
 
+// NewWatcher creates an instance of Watcher. stopCh is closed when the
+// watcher is stopped; the sender must not close it.
 func NewWatcher(ch chan watch.Event, stopCh chan bool) *Watcher {
 	w := Watcher{
 		ch:     ch,
@@ -20,10 +27,15 @@ func NewWatcher(ch chan watch.Event, stopCh chan bool) *Watcher {
 	return &w
 }
 
-func (w Watcher) Stop() {
-	w.stopCh <- true
+// Stop stops the watcher. It doesn't block, and it can be called more than
+// once.
+func (w *Watcher) Stop() {
+	w.once.Do(func() {
+		close(w.stopCh)
+	})
 }
 
-func (w Watcher) ResultChan() <-chan watch.Event {
+// ResultChan returns the watch events.
+func (w *Watcher) ResultChan() <-chan watch.Event {
 	return w.ch
 }
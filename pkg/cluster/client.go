@@ -5,6 +5,7 @@ import (
 
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 )
 
@@ -14,5 +15,33 @@ import (
 type Client interface {
 	List(ctx context.Context, res schema.GroupVersionResource, options ListOptions) (*unstructured.UnstructuredList, error)
 	Watch(ctx context.Context, res schema.GroupVersionResource, options ListOptions) (Watch, error)
+	// Resources lists the resources in the cluster. Groups are in
+	// discovery priority order.
 	Resources() (Resources, error)
+	// Get gets an object by name.
+	Get(ctx context.Context, res schema.GroupVersionResource, name string, options GetOptions) (*unstructured.Unstructured, error)
+	// Create creates an object.
+	Create(ctx context.Context, res schema.GroupVersionResource, object *unstructured.Unstructured, options CreateOptions) (*unstructured.Unstructured, error)
+	// Update updates an object.
+	Update(ctx context.Context, res schema.GroupVersionResource, object *unstructured.Unstructured, options UpdateOptions) (*unstructured.Unstructured, error)
+	// Patch patches an object by name. The patch type can be a JSON, merge,
+	// or strategic merge patch.
+	Patch(ctx context.Context, res schema.GroupVersionResource, name string, patchType types.PatchType, data []byte, options PatchOptions) (*unstructured.Unstructured, error)
+	// Apply applies an object using server-side apply. The object's name
+	// and namespace are used.
+	Apply(ctx context.Context, res schema.GroupVersionResource, object *unstructured.Unstructured, options ApplyOptions) (*unstructured.Unstructured, error)
+	// Delete deletes an object by name.
+	Delete(ctx context.Context, res schema.GroupVersionResource, name string, options DeleteOptions) error
+	// DeleteCollection deletes the objects matching the list options.
+	DeleteCollection(ctx context.Context, res schema.GroupVersionResource, options DeleteOptions, listOptions ListOptions) error
+	// Evict evicts a pod by name using the pods/eviction subresource, so
+	// pod disruption budgets are respected.
+	Evict(ctx context.Context, name string, options DeleteOptions) error
+}
+
+// Invalidator is implemented by clients that cache discovery information.
+type Invalidator interface {
+	// Invalidate discards cached discovery information, so the next call to
+	// Resources reads from the cluster.
+	Invalidate()
 }
@@ -2,10 +2,19 @@ package cluster
 
 import metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
+// InitialEventsEndAnnotation is set to "true" on the bookmark that ends the
+// initial events of a watch.
+const InitialEventsEndAnnotation = "k8s.io/initial-events-end"
+
 // ListOptions wraps metav1.ListOptions and adds a Namespace key.
 type ListOptions struct {
 	metav1.ListOptions
 
 	// Namespace is the namespace to scope the returned objects.
 	Namespace string
+
+	// SendInitialEvents makes a watch start with an added event for each
+	// existing object, followed by a bookmark event annotated with
+	// InitialEventsEndAnnotation. The resource version is ignored.
+	SendInitialEvents bool
 }
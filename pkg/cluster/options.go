@@ -0,0 +1,58 @@
+package cluster
+
+import metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+// GetOptions wraps metav1.GetOptions and adds a Namespace key.
+type GetOptions struct {
+	metav1.GetOptions
+
+	// Namespace is the namespace of the object.
+	Namespace string
+	// Subresource is the subresource to get, e.g. status or scale.
+	Subresource string
+}
+
+// CreateOptions wraps metav1.CreateOptions. The namespace is taken from
+// the object being created.
+type CreateOptions struct {
+	metav1.CreateOptions
+}
+
+// UpdateOptions wraps metav1.UpdateOptions. The namespace is taken from
+// the object being updated.
+type UpdateOptions struct {
+	metav1.UpdateOptions
+
+	// Subresource is the subresource to update, e.g. status or scale.
+	Subresource string
+}
+
+// PatchOptions wraps metav1.PatchOptions and adds a Namespace key.
+type PatchOptions struct {
+	metav1.PatchOptions
+
+	// Namespace is the namespace of the object.
+	Namespace string
+	// Subresource is the subresource to patch, e.g. status or scale.
+	Subresource string
+}
+
+// DeleteOptions wraps metav1.DeleteOptions and adds a Namespace key.
+type DeleteOptions struct {
+	metav1.DeleteOptions
+
+	// Namespace is the namespace of the object. It is ignored when
+	// deleting collections; the list options namespace is used instead.
+	Namespace string
+}
+
+// ApplyOptions configures a server-side apply.
+type ApplyOptions struct {
+	// FieldManager is the name of the manager applying the object. It
+	// is required.
+	FieldManager string
+	// Force takes ownership of fields that conflict with other managers.
+	Force bool
+	// DryRun applies the object without persisting it.
+	DryRun bool
+}
@@ -0,0 +1,92 @@
+package cluster
+
+import (
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// Resolve finds the resource named by user input, the way kubectl does.
+// The input is a resource name, singular name, short name, or kind,
+// optionally qualified by group (deploy.apps) or by version and group
+// (deployments.v1.apps). Names and singular names are matched before short
+// names, and short names before kinds. If more than one group matches, the
+// first one in the list is picked, so a list in discovery order resolves
+// the way kubectl's priority REST mapper does. A meta.NoResourceMatchError
+// is returned if nothing matches.
+func (rl Resources) Resolve(input string) (Resource, error) {
+	fullySpecified, groupResource := schema.ParseResourceArg(strings.ToLower(input))
+
+	// resource.version.group is tried as version and group first, and as a
+	// group named version.group after.
+	if fullySpecified != nil {
+		if r, err := pickResource(*fullySpecified, rl.matches(*fullySpecified)); err == nil {
+			return r, nil
+		}
+	}
+
+	partial := schema.GroupVersionResource{
+		Group:    groupResource.Group,
+		Resource: groupResource.Resource,
+	}
+
+	return pickResource(partial, rl.matches(partial))
+}
+
+// matches returns the resources matching partial in the first tier that
+// has matches: names and singular names, then short names, then kinds.
+// Subresources are never matched.
+func (rl Resources) matches(partial schema.GroupVersionResource) []Resource {
+	tiers := []func(r Resource) bool{
+		func(r Resource) bool {
+			return strings.ToLower(r.Name()) == partial.Resource ||
+				strings.ToLower(r.SingularName()) == partial.Resource
+		},
+		func(r Resource) bool {
+			for _, shortName := range r.ShortNames() {
+				if strings.ToLower(shortName) == partial.Resource {
+					return true
+				}
+			}
+			return false
+		},
+		func(r Resource) bool {
+			return strings.ToLower(r.GroupVersionKind().Kind) == partial.Resource
+		},
+	}
+
+	for _, isMatch := range tiers {
+		var list []Resource
+
+		for _, r := range rl {
+			gvr := r.GroupVersionResource()
+
+			if strings.Contains(gvr.Resource, "/") ||
+				(partial.Group != "" && gvr.Group != partial.Group) ||
+				(partial.Version != "" && gvr.Version != partial.Version) {
+				continue
+			}
+
+			if isMatch(r) {
+				list = append(list, r)
+			}
+		}
+
+		if len(list) > 0 {
+			return list
+		}
+	}
+
+	return nil
+}
+
+// pickResource picks the resource for partial from its matches. Matches
+// are in the order of the list, so the first is the highest priority.
+func pickResource(partial schema.GroupVersionResource, matches []Resource) (Resource, error) {
+	if len(matches) == 0 {
+		return nil, &meta.NoResourceMatchError{PartialResource: partial}
+	}
+
+	return matches[0], nil
+}
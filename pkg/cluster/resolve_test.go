@@ -0,0 +1,135 @@
+package cluster
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+type resource struct {
+	groupVersion schema.GroupVersion
+	name         string
+	singularName string
+	kind         string
+	shortNames   []string
+}
+
+func (r resource) GroupVersionKind() schema.GroupVersionKind {
+	return r.groupVersion.WithKind(r.kind)
+}
+func (r resource) GroupVersionResource() schema.GroupVersionResource {
+	return r.groupVersion.WithResource(r.name)
+}
+func (r resource) Verbs() []string        { return nil }
+func (r resource) Name() string           { return r.name }
+func (r resource) Categories() []string   { return nil }
+func (r resource) IsNamespaced() bool     { return true }
+func (r resource) ShortNames() []string   { return r.shortNames }
+func (r resource) SingularName() string   { return r.singularName }
+func (r resource) Subresources() []string { return nil }
+
+func TestResources_Resolve(t *testing.T) {
+	core := schema.GroupVersion{Version: "v1"}
+	apps := schema.GroupVersion{Group: "apps", Version: "v1"}
+	extensions := schema.GroupVersion{Group: "extensions", Version: "v1beta1"}
+	events := schema.GroupVersion{Group: "events.k8s.io", Version: "v1beta1"}
+	example := schema.GroupVersion{Group: "v1.example.com", Version: "v1"}
+
+	// resources are in discovery priority order.
+	resources := Resources{
+		resource{groupVersion: core, name: "pods", singularName: "pod", kind: "Pod", shortNames: []string{"po"}},
+		resource{groupVersion: core, name: "pods/log", kind: "Pod"},
+		resource{groupVersion: core, name: "events", singularName: "event", kind: "Event", shortNames: []string{"ev"}},
+		resource{groupVersion: apps, name: "deployments", singularName: "deployment", kind: "Deployment", shortNames: []string{"deploy"}},
+		resource{groupVersion: extensions, name: "deployments", singularName: "deployment", kind: "Deployment", shortNames: []string{"deploy"}},
+		resource{groupVersion: events, name: "events", singularName: "event", kind: "Event", shortNames: []string{"ev"}},
+		resource{groupVersion: example, name: "widgets", singularName: "widget", kind: "Widget"},
+	}
+
+	tests := []struct {
+		name       string
+		input      string
+		wanted     schema.GroupVersionResource
+		isNotFound bool
+	}{
+		{
+			name:   "name",
+			input:  "pods",
+			wanted: core.WithResource("pods"),
+		},
+		{
+			name:   "singular name",
+			input:  "pod",
+			wanted: core.WithResource("pods"),
+		},
+		{
+			name:   "short name",
+			input:  "po",
+			wanted: core.WithResource("pods"),
+		},
+		{
+			name:   "kind",
+			input:  "Pod",
+			wanted: core.WithResource("pods"),
+		},
+		{
+			name:   "short name with group",
+			input:  "deploy.apps",
+			wanted: apps.WithResource("deployments"),
+		},
+		{
+			name:   "name with version and group",
+			input:  "deployments.v1beta1.extensions",
+			wanted: extensions.WithResource("deployments"),
+		},
+		{
+			name:   "name with group",
+			input:  "events.events.k8s.io",
+			wanted: events.WithResource("events"),
+		},
+		{
+			name:   "group that looks like a version and group",
+			input:  "widgets.v1.example.com",
+			wanted: example.WithResource("widgets"),
+		},
+		{
+			name:   "first group is picked",
+			input:  "deployments",
+			wanted: apps.WithResource("deployments"),
+		},
+		{
+			name:   "first group is picked for short names",
+			input:  "ev",
+			wanted: core.WithResource("events"),
+		},
+		{
+			name:       "group that does not have the resource",
+			input:      "pods.apps",
+			isNotFound: true,
+		},
+		{
+			name:       "subresource",
+			input:      "pods/log",
+			isNotFound: true,
+		},
+		{
+			name:       "unknown",
+			input:      "unknown",
+			isNotFound: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r, err := resources.Resolve(test.input)
+			if test.isNotFound {
+				require.True(t, meta.IsNoMatchError(err), "got %v", err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, test.wanted, r.GroupVersionResource())
+		})
+	}
+}
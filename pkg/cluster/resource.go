@@ -16,6 +16,13 @@ type Resource interface {
 	Categories() []string
 	// IsNamespaced returns true if the resource is namespaced.
 	IsNamespaced() bool
+	// ShortNames returns the short names for the resource, e.g. po for pods.
+	ShortNames() []string
+	// SingularName returns the singular name for the resource, e.g. pod.
+	SingularName() string
+	// Subresources returns the names of the resource's subresources, e.g.
+	// status and scale.
+	Subresources() []string
 }
 
 // Resources is a list of Resource.
@@ -32,6 +39,17 @@ func (rl Resources) GroupVersionKind(groupVersionKind schema.GroupVersionKind) (
 	return nil, false
 }
 
+// GroupVersionResource returns a resource in the list by group/version/resource.
+func (rl Resources) GroupVersionResource(groupVersionResource schema.GroupVersionResource) (Resource, bool) {
+	for _, r := range rl {
+		if groupVersionResource == r.GroupVersionResource() {
+			return r, true
+		}
+	}
+
+	return nil, false
+}
+
 // NamespacedScoped returns namespace scoped resources.
 func (rl Resources) NamespacedScoped() []Resource {
 	var list []Resource
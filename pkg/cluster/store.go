@@ -4,6 +4,7 @@ import (
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
 )
 
 //go:generate mockgen -destination=../mocks/mock_store.go -package mocks github.com/bryanl/clientkube/pkg/cluster Store
@@ -16,8 +17,22 @@ type Store interface {
 	Update(res schema.GroupVersionResource, object runtime.Object)
 	// Update deletes the object given a group/version/resource.
 	Delete(res schema.GroupVersionResource, object runtime.Object)
+	// Get gets an object in a group/version/resource by namespace and name. It
+	// returns false if the object isn't in the store.
+	Get(res schema.GroupVersionResource, namespace, name string) (*unstructured.Unstructured, bool)
+	// GetByUID gets an object by UID from any group/version/resource. It
+	// returns false if the object isn't in the store.
+	GetByUID(uid types.UID) (schema.GroupVersionResource, *unstructured.Unstructured, bool)
 	// List lists objects in the store given a group/version/resource and list options.
 	List(res schema.GroupVersionResource, options ListOptions) (*unstructured.UnstructuredList, error)
 	// Watch watches objects in a given group/version/resource for updates.
 	Watch(res schema.GroupVersionResource, options ListOptions) (Watch, error)
+	// AddIndexer adds an index named indexName to a group/version/resource.
+	AddIndexer(res schema.GroupVersionResource, indexName string, indexFunc IndexFunc) error
+	// ByIndex lists objects in a group/version/resource whose indexed values
+	// for indexName include value.
+	ByIndex(res schema.GroupVersionResource, indexName, value string) ([]*unstructured.Unstructured, error)
 }
+
+// IndexFunc returns the values an object is indexed by.
+type IndexFunc func(object *unstructured.Unstructured) ([]string, error)
@@ -12,6 +12,7 @@ import (
 	unstructured "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 	schema "k8s.io/apimachinery/pkg/runtime/schema"
+	types "k8s.io/apimachinery/pkg/types"
 )
 
 // MockStore is a mock of Store interface
@@ -49,6 +50,35 @@ func (mr *MockStoreMockRecorder) Add(arg0, arg1 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Add", reflect.TypeOf((*MockStore)(nil).Add), arg0, arg1)
 }
 
+// AddIndexer mocks base method
+func (m *MockStore) AddIndexer(arg0 schema.GroupVersionResource, arg1 string, arg2 cluster.IndexFunc) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddIndexer", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddIndexer indicates an expected call of AddIndexer
+func (mr *MockStoreMockRecorder) AddIndexer(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddIndexer", reflect.TypeOf((*MockStore)(nil).AddIndexer), arg0, arg1, arg2)
+}
+
+// ByIndex mocks base method
+func (m *MockStore) ByIndex(arg0 schema.GroupVersionResource, arg1, arg2 string) ([]*unstructured.Unstructured, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ByIndex", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]*unstructured.Unstructured)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ByIndex indicates an expected call of ByIndex
+func (mr *MockStoreMockRecorder) ByIndex(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ByIndex", reflect.TypeOf((*MockStore)(nil).ByIndex), arg0, arg1, arg2)
+}
+
 // Delete mocks base method
 func (m *MockStore) Delete(arg0 schema.GroupVersionResource, arg1 runtime.Object) {
 	m.ctrl.T.Helper()
@@ -61,6 +91,37 @@ func (mr *MockStoreMockRecorder) Delete(arg0, arg1 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockStore)(nil).Delete), arg0, arg1)
 }
 
+// Get mocks base method
+func (m *MockStore) Get(arg0 schema.GroupVersionResource, arg1, arg2 string) (*unstructured.Unstructured, bool) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*unstructured.Unstructured)
+	ret1, _ := ret[1].(bool)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get
+func (mr *MockStoreMockRecorder) Get(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockStore)(nil).Get), arg0, arg1, arg2)
+}
+
+// GetByUID mocks base method
+func (m *MockStore) GetByUID(arg0 types.UID) (schema.GroupVersionResource, *unstructured.Unstructured, bool) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByUID", arg0)
+	ret0, _ := ret[0].(schema.GroupVersionResource)
+	ret1, _ := ret[1].(*unstructured.Unstructured)
+	ret2, _ := ret[2].(bool)
+	return ret0, ret1, ret2
+}
+
+// GetByUID indicates an expected call of GetByUID
+func (mr *MockStoreMockRecorder) GetByUID(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByUID", reflect.TypeOf((*MockStore)(nil).GetByUID), arg0)
+}
+
 // List mocks base method
 func (m *MockStore) List(arg0 schema.GroupVersionResource, arg1 cluster.ListOptions) (*unstructured.UnstructuredList, error) {
 	m.ctrl.T.Helper()
@@ -0,0 +1,491 @@
+// Package ownership builds an ownership graph from the objects in a store
+// by following their owner references.
+package ownership
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+
+	"github.com/bryanl/clientkube/pkg/cluster"
+)
+
+// Object identifies an object in the graph.
+type Object struct {
+	// Resource is the group/version/resource the object was seen in. An
+	// object seen in more than one is reported in the one that sorts first.
+	Resource  schema.GroupVersionResource
+	UID       types.UID
+	Namespace string
+	Name      string
+}
+
+// node is an object and the owners it refers to.
+type node struct {
+	object    Object
+	resources map[schema.GroupVersionResource]bool
+	owners    []metav1.OwnerReference
+}
+
+// Graph is the ownership graph of the objects in a store. It is updated
+// from store watches, so it follows the store as objects are added,
+// updated, and deleted. Only objects of the resources the graph was
+// started with are in the graph; owners of other resources are looked up
+// in the store by UID. It is safe for concurrent use.
+type Graph struct {
+	store         cluster.Store
+	logger        logr.Logger
+	retryInterval time.Duration
+
+	nodes map[types.UID]*node
+	// children are the UIDs of the objects that refer to an owner UID. An
+	// owner doesn't have to be in the graph.
+	children map[types.UID]map[types.UID]bool
+
+	cancel    context.CancelFunc
+	waitGroup sync.WaitGroup
+
+	mu sync.RWMutex
+}
+
+// NewGraph creates an instance of Graph.
+func NewGraph(store cluster.Store, optionList ...Option) *Graph {
+	opts := currentOptions(optionList...)
+
+	g := Graph{
+		store:         store,
+		logger:        opts.logger.WithValues("component", "ownership.Graph"),
+		retryInterval: opts.retryInterval,
+		nodes:         map[types.UID]*node{},
+		children:      map[types.UID]map[types.UID]bool{},
+	}
+
+	return &g
+}
+
+// Start watches resources in the store and adds their objects to the
+// graph. It returns once the objects already in the store are in the
+// graph. The graph is updated until ctx is done or the graph is stopped.
+func (g *Graph) Start(ctx context.Context, resources ...schema.GroupVersionResource) error {
+	g.mu.Lock()
+	if g.cancel != nil {
+		g.mu.Unlock()
+		return errors.New("graph is already started")
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	g.cancel = cancel
+	g.mu.Unlock()
+
+	synced := make(chan struct{}, len(resources))
+
+	for _, res := range resources {
+		res := res
+
+		g.waitGroup.Add(1)
+		go func() {
+			defer g.waitGroup.Done()
+			g.watchResource(ctx, res, synced)
+		}()
+	}
+
+	for range resources {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-synced:
+		}
+	}
+
+	return nil
+}
+
+// Stop stops updating the graph and waits for its watches to stop.
+func (g *Graph) Stop() {
+	g.mu.RLock()
+	cancel := g.cancel
+	g.mu.RUnlock()
+
+	if cancel != nil {
+		cancel()
+	}
+
+	g.waitGroup.Wait()
+}
+
+// Children returns the objects owned by uid.
+func (g *Graph) Children(uid types.UID) []Object {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	var objects []Object
+	for child := range g.children[uid] {
+		if n, ok := g.nodes[child]; ok {
+			objects = append(objects, n.object)
+		}
+	}
+
+	sortObjects(objects)
+
+	return objects
+}
+
+// Descendants returns the objects owned by uid, the objects they own, and
+// so on.
+func (g *Graph) Descendants(uid types.UID) []Object {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	visited := map[types.UID]bool{uid: true}
+	queue := []types.UID{uid}
+
+	var objects []Object
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		for child := range g.children[current] {
+			n, ok := g.nodes[child]
+			if !ok || visited[child] {
+				continue
+			}
+
+			visited[child] = true
+			objects = append(objects, n.object)
+			queue = append(queue, child)
+		}
+	}
+
+	sortObjects(objects)
+
+	return objects
+}
+
+// Owners returns the owners of uid that are in the graph or the store, in
+// the order of its owner references.
+func (g *Graph) Owners(uid types.UID) []Object {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	n, ok := g.nodes[uid]
+	if !ok {
+		return nil
+	}
+
+	var objects []Object
+	for _, ref := range n.owners {
+		if owner, ok := g.lookup(ref.UID); ok {
+			objects = append(objects, owner.object)
+		}
+	}
+
+	return objects
+}
+
+// RootOwner follows the owners of uid until it finds an object without an
+// owner in the graph or the store. The controller owner is followed if it
+// is found, otherwise the first owner that is. An object without owners is
+// its own root owner. It returns false if uid isn't in the graph.
+func (g *Graph) RootOwner(uid types.UID) (Object, bool) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	n, ok := g.nodes[uid]
+	if !ok {
+		return Object{}, false
+	}
+
+	visited := map[types.UID]bool{uid: true}
+
+	for {
+		owner := g.owner(n)
+		if owner == nil || visited[owner.object.UID] {
+			return n.object, true
+		}
+
+		visited[owner.object.UID] = true
+		n = owner
+	}
+}
+
+// Orphans returns the objects with owner references whose owners are in
+// neither the graph nor the store.
+func (g *Graph) Orphans() []Object {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	var objects []Object
+	for _, n := range g.nodes {
+		if len(n.owners) > 0 && g.owner(n) == nil {
+			objects = append(objects, n.object)
+		}
+	}
+
+	sortObjects(objects)
+
+	return objects
+}
+
+// owner returns the owner of n to follow to its root: its controller if it
+// is found, otherwise its first owner that is. The caller must hold the
+// lock.
+func (g *Graph) owner(n *node) *node {
+	var first *node
+
+	for _, ref := range n.owners {
+		owner, ok := g.lookup(ref.UID)
+		if !ok {
+			continue
+		}
+
+		if ref.Controller != nil && *ref.Controller {
+			return owner
+		}
+
+		if first == nil {
+			first = owner
+		}
+	}
+
+	return first
+}
+
+// lookup returns the node for uid. An object that isn't in the graph, such
+// as an owner of a resource the graph doesn't watch, is looked up in the
+// store. The caller must hold the lock.
+func (g *Graph) lookup(uid types.UID) (*node, bool) {
+	if n, ok := g.nodes[uid]; ok {
+		return n, true
+	}
+
+	res, u, ok := g.store.GetByUID(uid)
+	if !ok {
+		return nil, false
+	}
+
+	n := node{
+		object: Object{
+			Resource:  res,
+			UID:       uid,
+			Namespace: u.GetNamespace(),
+			Name:      u.GetName(),
+		},
+		owners: u.GetOwnerReferences(),
+	}
+
+	return &n, true
+}
+
+// watchResource keeps the graph up to date with the objects of a resource.
+// synced is notified once the first initial events are in the graph. A
+// watch that ends is replaced by a new one, whose initial events replace
+// the resource's objects.
+func (g *Graph) watchResource(ctx context.Context, res schema.GroupVersionResource, synced chan<- struct{}) {
+	logger := g.logger.WithValues("res", res)
+
+	for ctx.Err() == nil {
+		w, err := g.store.Watch(res, cluster.ListOptions{SendInitialEvents: true})
+		if err != nil {
+			logger.Error(err, "watch store")
+		} else {
+			if g.processEvents(ctx, res, w, synced) {
+				synced = nil
+			}
+			w.Stop()
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(g.retryInterval):
+		}
+	}
+}
+
+// processEvents applies the events of a store watch to the graph until the
+// watch ends or ctx is done. It returns true if the initial events were
+// applied.
+func (g *Graph) processEvents(
+	ctx context.Context,
+	res schema.GroupVersionResource,
+	w cluster.Watch,
+	synced chan<- struct{}) bool {
+	// seen is the objects in the initial events. It is cleared once they
+	// are applied.
+	seen := map[types.UID]bool{}
+	isSynced := false
+
+	for {
+		select {
+		case <-ctx.Done():
+			return isSynced
+		case e, ok := <-w.ResultChan():
+			if !ok {
+				return isSynced
+			}
+
+			switch e.Type {
+			case watch.Error:
+				g.logger.Info("store watch failed", "res", res, "status", e.Object)
+				return isSynced
+			case watch.Bookmark:
+				if seen != nil && isInitialEventsEnd(e) {
+					g.prune(res, seen)
+					seen = nil
+					isSynced = true
+
+					if synced != nil {
+						synced <- struct{}{}
+					}
+				}
+			case watch.Added, watch.Modified:
+				accessor, err := meta.Accessor(e.Object)
+				if err != nil {
+					continue
+				}
+
+				if seen != nil {
+					seen[accessor.GetUID()] = true
+				}
+
+				g.add(res, accessor)
+			case watch.Deleted:
+				accessor, err := meta.Accessor(e.Object)
+				if err != nil {
+					continue
+				}
+
+				g.remove(res, accessor.GetUID())
+			}
+		}
+	}
+}
+
+// add adds or updates an object seen in a resource.
+func (g *Graph) add(res schema.GroupVersionResource, object metav1.Object) {
+	uid := object.GetUID()
+	if uid == "" {
+		return
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	n, ok := g.nodes[uid]
+	if !ok {
+		n = &node{resources: map[schema.GroupVersionResource]bool{}}
+		g.nodes[uid] = n
+	}
+
+	g.unlinkOwners(n)
+
+	n.resources[res] = true
+	n.object = Object{
+		Resource:  firstResource(n.resources),
+		UID:       uid,
+		Namespace: object.GetNamespace(),
+		Name:      object.GetName(),
+	}
+	n.owners = object.GetOwnerReferences()
+
+	for _, ref := range n.owners {
+		children, ok := g.children[ref.UID]
+		if !ok {
+			children = map[types.UID]bool{}
+			g.children[ref.UID] = children
+		}
+		children[uid] = true
+	}
+}
+
+// remove removes an object seen in a resource. It stays in the graph until
+// it is removed from every resource it was seen in.
+func (g *Graph) remove(res schema.GroupVersionResource, uid types.UID) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.removeLocked(res, uid)
+}
+
+// prune removes the objects of a resource that aren't in seen.
+func (g *Graph) prune(res schema.GroupVersionResource, seen map[types.UID]bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	for uid, n := range g.nodes {
+		if n.resources[res] && !seen[uid] {
+			g.removeLocked(res, uid)
+		}
+	}
+}
+
+// removeLocked removes an object seen in a resource. The caller must hold
+// the lock.
+func (g *Graph) removeLocked(res schema.GroupVersionResource, uid types.UID) {
+	n, ok := g.nodes[uid]
+	if !ok {
+		return
+	}
+
+	delete(n.resources, res)
+	if len(n.resources) > 0 {
+		n.object.Resource = firstResource(n.resources)
+		return
+	}
+
+	g.unlinkOwners(n)
+	delete(g.nodes, uid)
+}
+
+// unlinkOwners removes n from the children of its owners. The caller must
+// hold the lock.
+func (g *Graph) unlinkOwners(n *node) {
+	for _, ref := range n.owners {
+		children := g.children[ref.UID]
+		delete(children, n.object.UID)
+		if len(children) == 0 {
+			delete(g.children, ref.UID)
+		}
+	}
+}
+
+func isInitialEventsEnd(e watch.Event) bool {
+	accessor, err := meta.Accessor(e.Object)
+	if err != nil {
+		return false
+	}
+
+	return accessor.GetAnnotations()[cluster.InitialEventsEndAnnotation] == "true"
+}
+
+func firstResource(resources map[schema.GroupVersionResource]bool) schema.GroupVersionResource {
+	var first schema.GroupVersionResource
+	for res := range resources {
+		if first.Empty() || res.String() < first.String() {
+			first = res
+		}
+	}
+
+	return first
+}
+
+func sortObjects(objects []Object) {
+	sort.Slice(objects, func(i, j int) bool {
+		a, b := objects[i], objects[j]
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.UID < b.UID
+	})
+}
@@ -0,0 +1,211 @@
+package ownership
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/bryanl/clientkube/pkg/clientkube"
+)
+
+var (
+	deployments = schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+	replicaSets = schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "replicasets"}
+	pods        = schema.GroupVersionResource{Version: "v1", Resource: "pods"}
+)
+
+func newObject(kind, name string, uid types.UID, owners ...*unstructured.Unstructured) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{}
+	u.SetAPIVersion("v1")
+	u.SetKind(kind)
+	u.SetNamespace("default")
+	u.SetName(name)
+	u.SetUID(uid)
+
+	var refs []metav1.OwnerReference
+	for i, owner := range owners {
+		isController := i == 0
+		refs = append(refs, metav1.OwnerReference{
+			APIVersion: owner.GetAPIVersion(),
+			Kind:       owner.GetKind(),
+			Name:       owner.GetName(),
+			UID:        owner.GetUID(),
+			Controller: &isController,
+		})
+	}
+	u.SetOwnerReferences(refs)
+
+	return u
+}
+
+func objectFor(res schema.GroupVersionResource, u *unstructured.Unstructured) Object {
+	return Object{
+		Resource:  res,
+		UID:       u.GetUID(),
+		Namespace: u.GetNamespace(),
+		Name:      u.GetName(),
+	}
+}
+
+func TestGraph(t *testing.T) {
+	deployment := newObject("Deployment", "deployment", "deployment-uid")
+	rs := newObject("ReplicaSet", "rs", "rs-uid", deployment)
+	pod1 := newObject("Pod", "pod1", "pod1-uid", rs)
+	pod2 := newObject("Pod", "pod2", "pod2-uid", rs)
+	missing := newObject("ReplicaSet", "missing", "missing-uid")
+	orphan := newObject("Pod", "orphan", "orphan-uid", missing)
+
+	store := clientkube.NewMemoryStore()
+	store.Add(deployments, deployment)
+	store.Add(replicaSets, rs)
+	store.Add(pods, pod1)
+	store.Add(pods, pod2)
+	store.Add(pods, orphan)
+
+	g := NewGraph(store)
+	require.NoError(t, g.Start(context.Background(), deployments, replicaSets, pods))
+	defer g.Stop()
+
+	tests := []struct {
+		name   string
+		query  func() interface{}
+		wanted interface{}
+	}{
+		{
+			name:   "children",
+			query:  func() interface{} { return g.Children(rs.GetUID()) },
+			wanted: []Object{objectFor(pods, pod1), objectFor(pods, pod2)},
+		},
+		{
+			name:  "descendants",
+			query: func() interface{} { return g.Descendants(deployment.GetUID()) },
+			wanted: []Object{
+				objectFor(pods, pod1),
+				objectFor(pods, pod2),
+				objectFor(replicaSets, rs),
+			},
+		},
+		{
+			name:   "owners",
+			query:  func() interface{} { return g.Owners(pod1.GetUID()) },
+			wanted: []Object{objectFor(replicaSets, rs)},
+		},
+		{
+			name: "root owner",
+			query: func() interface{} {
+				root, ok := g.RootOwner(pod1.GetUID())
+				require.True(t, ok)
+				return root
+			},
+			wanted: objectFor(deployments, deployment),
+		},
+		{
+			name: "root owner of object without owners",
+			query: func() interface{} {
+				root, ok := g.RootOwner(deployment.GetUID())
+				require.True(t, ok)
+				return root
+			},
+			wanted: objectFor(deployments, deployment),
+		},
+		{
+			name: "root owner of object not in graph",
+			query: func() interface{} {
+				_, ok := g.RootOwner(missing.GetUID())
+				return ok
+			},
+			wanted: false,
+		},
+		{
+			name:   "orphans",
+			query:  func() interface{} { return g.Orphans() },
+			wanted: []Object{objectFor(pods, orphan)},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.wanted, test.query())
+		})
+	}
+}
+
+func TestGraph_updates(t *testing.T) {
+	deployment := newObject("Deployment", "deployment", "deployment-uid")
+	rs := newObject("ReplicaSet", "rs", "rs-uid", deployment)
+	pod := newObject("Pod", "pod", "pod-uid", rs)
+
+	store := clientkube.NewMemoryStore()
+	store.Add(deployments, deployment)
+	store.Add(replicaSets, rs)
+
+	g := NewGraph(store)
+	require.NoError(t, g.Start(context.Background(), deployments, replicaSets, pods))
+	defer g.Stop()
+
+	require.Empty(t, g.Children(rs.GetUID()))
+
+	store.Add(pods, pod)
+	require.Eventually(t, func() bool {
+		return len(g.Descendants(deployment.GetUID())) == 2
+	}, time.Second, 10*time.Millisecond)
+
+	// the replica set is deleted, so the pod is orphaned.
+	store.Delete(replicaSets, rs)
+	require.Eventually(t, func() bool {
+		return len(g.Orphans()) == 1
+	}, time.Second, 10*time.Millisecond)
+	require.Equal(t, []Object{objectFor(pods, pod)}, g.Orphans())
+	require.Empty(t, g.Descendants(deployment.GetUID()))
+
+	root, ok := g.RootOwner(pod.GetUID())
+	require.True(t, ok)
+	require.Equal(t, objectFor(pods, pod), root)
+
+	// the pod is adopted by the deployment.
+	store.Update(pods, newObject("Pod", "pod", "pod-uid", deployment))
+	require.Eventually(t, func() bool {
+		return len(g.Orphans()) == 0
+	}, time.Second, 10*time.Millisecond)
+	require.Equal(t, []Object{objectFor(pods, pod)}, g.Children(deployment.GetUID()))
+}
+
+func TestGraph_unwatchedOwners(t *testing.T) {
+	deployment := newObject("Deployment", "deployment", "deployment-uid")
+	rs := newObject("ReplicaSet", "rs", "rs-uid", deployment)
+	pod := newObject("Pod", "pod", "pod-uid", rs)
+	missing := newObject("ReplicaSet", "missing", "missing-uid")
+	orphan := newObject("Pod", "orphan", "orphan-uid", missing)
+
+	store := clientkube.NewMemoryStore()
+	store.Add(deployments, deployment)
+	store.Add(replicaSets, rs)
+	store.Add(pods, pod)
+	store.Add(pods, orphan)
+
+	// only pods are watched; their owners are found in the store.
+	g := NewGraph(store)
+	require.NoError(t, g.Start(context.Background(), pods))
+	defer g.Stop()
+
+	require.Equal(t, []Object{objectFor(replicaSets, rs)}, g.Owners(pod.GetUID()))
+
+	root, ok := g.RootOwner(pod.GetUID())
+	require.True(t, ok)
+	require.Equal(t, objectFor(deployments, deployment), root)
+
+	require.Equal(t, []Object{objectFor(pods, orphan)}, g.Orphans())
+}
+
+func TestGraph_Start_twice(t *testing.T) {
+	g := NewGraph(clientkube.NewMemoryStore())
+	require.NoError(t, g.Start(context.Background(), pods))
+	defer g.Stop()
+
+	require.Error(t, g.Start(context.Background(), pods))
+}
@@ -0,0 +1,44 @@
+package ownership
+
+import (
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/go-logr/logr/testing"
+)
+
+type options struct {
+	logger        logr.Logger
+	retryInterval time.Duration
+}
+
+func currentOptions(list ...Option) options {
+	opts := options{
+		logger:        &testing.NullLogger{},
+		retryInterval: time.Second,
+	}
+
+	for _, o := range list {
+		o(&opts)
+	}
+
+	return opts
+}
+
+// Option configures a Graph.
+type Option func(o *options)
+
+// WithLogger sets the logger.
+func WithLogger(logger logr.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+// WithRetryInterval sets how long a graph waits before watching a resource
+// again after its store watch failed.
+func WithRetryInterval(interval time.Duration) Option {
+	return func(o *options) {
+		o.retryInterval = interval
+	}
+}
@@ -0,0 +1,458 @@
+// Package relationship resolves the label selector based relationships
+// between pods and the objects that select them.
+package relationship
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+
+	"github.com/bryanl/clientkube/pkg/cluster"
+)
+
+// Kind is a kind of object that selects pods.
+type Kind string
+
+const (
+	// Service selects pods with spec.selector.
+	Service Kind = "Service"
+	// PodDisruptionBudget selects pods with spec.selector.
+	PodDisruptionBudget Kind = "PodDisruptionBudget"
+	// NetworkPolicy selects pods with spec.podSelector.
+	NetworkPolicy Kind = "NetworkPolicy"
+	// HorizontalPodAutoscaler selects the pods of the workload in
+	// spec.scaleTargetRef.
+	HorizontalPodAutoscaler Kind = "HorizontalPodAutoscaler"
+)
+
+// kinds are the kinds an engine resolves, in the order they are resolved.
+var kinds = []Kind{Service, PodDisruptionBudget, NetworkPolicy, HorizontalPodAutoscaler}
+
+var pods = schema.GroupVersionResource{Version: "v1", Resource: "pods"}
+
+// selectorKey identifies an object that selects pods.
+type selectorKey struct {
+	kind Kind
+	name string
+}
+
+// objectKey identifies an object in a resource.
+type objectKey struct {
+	res schema.GroupVersionResource
+	types.NamespacedName
+}
+
+// namespaceCache is the resolved relationships in a namespace.
+type namespaceCache struct {
+	pods      map[selectorKey][]string
+	selectors map[string][]selectorKey
+}
+
+// Engine resolves the pods selected by services, pod disruption budgets,
+// network policies, and horizontal pod autoscalers in a store, and the
+// reverse. Relationships are resolved once per namespace and cached until
+// a pod's labels or the selector of a selecting object or workload in the
+// namespace changes. It is safe for concurrent use.
+type Engine struct {
+	store         cluster.Store
+	logger        logr.Logger
+	retryInterval time.Duration
+	resources     map[Kind]schema.GroupVersionResource
+	workloads     map[string]schema.GroupVersionResource
+
+	caches map[string]*namespaceCache
+	// generations count the invalidations of each namespace, so a cache
+	// resolved while the namespace changed isn't kept.
+	generations map[string]uint64
+	podLabels   map[types.NamespacedName]labels.Set
+	// selectors are the selector fields of selecting objects and workloads.
+	selectors map[objectKey]interface{}
+
+	cancel    context.CancelFunc
+	waitGroup sync.WaitGroup
+
+	mu sync.Mutex
+}
+
+// NewEngine creates an instance of Engine.
+func NewEngine(store cluster.Store, optionList ...Option) *Engine {
+	opts := currentOptions(optionList...)
+
+	e := Engine{
+		store:         store,
+		logger:        opts.logger.WithValues("component", "relationship.Engine"),
+		retryInterval: opts.retryInterval,
+		resources:     opts.resources,
+		workloads:     opts.workloads,
+		caches:        map[string]*namespaceCache{},
+		generations:   map[string]uint64{},
+		podLabels:     map[types.NamespacedName]labels.Set{},
+		selectors:     map[objectKey]interface{}{},
+	}
+
+	return &e
+}
+
+// Start watches the store for changes that invalidate cached
+// relationships. It returns once the objects already in the store have
+// been seen. Until it is started, relationships are resolved but not
+// cached.
+func (e *Engine) Start(ctx context.Context) error {
+	e.mu.Lock()
+	if e.cancel != nil {
+		e.mu.Unlock()
+		return errors.New("engine is already started")
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	e.cancel = cancel
+	e.mu.Unlock()
+
+	resources := []schema.GroupVersionResource{pods}
+	for _, kind := range kinds {
+		resources = append(resources, e.resources[kind])
+	}
+	for _, res := range e.workloads {
+		resources = append(resources, res)
+	}
+
+	synced := make(chan struct{}, len(resources))
+
+	for _, res := range resources {
+		res := res
+
+		e.waitGroup.Add(1)
+		go func() {
+			defer e.waitGroup.Done()
+			e.watchResource(ctx, res, synced)
+		}()
+	}
+
+	for range resources {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-synced:
+		}
+	}
+
+	return nil
+}
+
+// Stop stops watching the store and waits for its watches to stop.
+func (e *Engine) Stop() {
+	e.mu.Lock()
+	cancel := e.cancel
+	e.mu.Unlock()
+
+	if cancel != nil {
+		cancel()
+	}
+
+	e.waitGroup.Wait()
+}
+
+// Pods returns the pods selected by an object of a kind.
+func (e *Engine) Pods(kind Kind, namespace, name string) ([]types.NamespacedName, error) {
+	if _, ok := e.resources[kind]; !ok {
+		return nil, fmt.Errorf("unknown kind %q", kind)
+	}
+
+	cache, err := e.namespaceCache(namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	var list []types.NamespacedName
+	for _, pod := range cache.pods[selectorKey{kind: kind, name: name}] {
+		list = append(list, types.NamespacedName{Namespace: namespace, Name: pod})
+	}
+
+	return list, nil
+}
+
+// Selecting returns the objects of a kind that select a pod.
+func (e *Engine) Selecting(kind Kind, namespace, podName string) ([]types.NamespacedName, error) {
+	if _, ok := e.resources[kind]; !ok {
+		return nil, fmt.Errorf("unknown kind %q", kind)
+	}
+
+	cache, err := e.namespaceCache(namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	var list []types.NamespacedName
+	for _, key := range cache.selectors[podName] {
+		if key.kind == kind {
+			list = append(list, types.NamespacedName{Namespace: namespace, Name: key.name})
+		}
+	}
+
+	return list, nil
+}
+
+// namespaceCache returns the relationships in a namespace, resolving them
+// if they aren't cached.
+func (e *Engine) namespaceCache(namespace string) (*namespaceCache, error) {
+	e.mu.Lock()
+	cache, ok := e.caches[namespace]
+	generation := e.generations[namespace]
+	isStarted := e.cancel != nil
+	e.mu.Unlock()
+
+	if ok {
+		return cache, nil
+	}
+
+	cache, err := e.resolve(namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	if !isStarted {
+		return cache, nil
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.generations[namespace] == generation {
+		e.caches[namespace] = cache
+	}
+
+	return cache, nil
+}
+
+// resolve resolves the relationships in a namespace.
+func (e *Engine) resolve(namespace string) (*namespaceCache, error) {
+	podList, err := e.store.List(pods, cluster.ListOptions{Namespace: namespace})
+	if err != nil {
+		return nil, fmt.Errorf("list pods: %w", err)
+	}
+
+	cache := &namespaceCache{
+		pods:      map[selectorKey][]string{},
+		selectors: map[string][]selectorKey{},
+	}
+
+	for _, kind := range kinds {
+		res := e.resources[kind]
+
+		list, err := e.store.List(res, cluster.ListOptions{Namespace: namespace})
+		if err != nil {
+			return nil, fmt.Errorf("list %s: %w", res, err)
+		}
+
+		for i := range list.Items {
+			object := &list.Items[i]
+
+			selector, err := e.podSelector(kind, res, object)
+			if err != nil {
+				e.logger.Error(err, "unable to read pod selector",
+					"kind", kind,
+					"namespace", namespace,
+					"name", object.GetName())
+				continue
+			}
+
+			if selector == nil {
+				continue
+			}
+
+			key := selectorKey{kind: kind, name: object.GetName()}
+			for j := range podList.Items {
+				pod := &podList.Items[j]
+				if selector.Matches(labels.Set(pod.GetLabels())) {
+					cache.pods[key] = append(cache.pods[key], pod.GetName())
+					cache.selectors[pod.GetName()] = append(cache.selectors[pod.GetName()], key)
+				}
+			}
+		}
+	}
+
+	for _, names := range cache.pods {
+		sort.Strings(names)
+	}
+
+	for _, keys := range cache.selectors {
+		sort.Slice(keys, func(i, j int) bool {
+			return keys[i].name < keys[j].name
+		})
+	}
+
+	return cache, nil
+}
+
+// invalidate drops the cached relationships in a namespace.
+func (e *Engine) invalidate(namespace string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	delete(e.caches, namespace)
+	e.generations[namespace]++
+}
+
+// watchResource invalidates cached relationships when objects of a
+// resource change. synced is notified once the objects already in the
+// store have been seen. A watch that ends is replaced by a new one.
+func (e *Engine) watchResource(ctx context.Context, res schema.GroupVersionResource, synced chan<- struct{}) {
+	logger := e.logger.WithValues("res", res)
+
+	for ctx.Err() == nil {
+		w, err := e.store.Watch(res, cluster.ListOptions{SendInitialEvents: true})
+		if err != nil {
+			logger.Error(err, "watch store")
+		} else {
+			if e.processEvents(ctx, res, w, synced) {
+				synced = nil
+			}
+			w.Stop()
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(e.retryInterval):
+		}
+	}
+}
+
+// processEvents invalidates cached relationships for the events of a store
+// watch until the watch ends or ctx is done. It returns true if the
+// initial events were seen.
+func (e *Engine) processEvents(
+	ctx context.Context,
+	res schema.GroupVersionResource,
+	w cluster.Watch,
+	synced chan<- struct{}) bool {
+	isSynced := false
+	fields := e.selectorFields(res)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return isSynced
+		case event, ok := <-w.ResultChan():
+			if !ok {
+				return isSynced
+			}
+
+			accessor, err := meta.Accessor(event.Object)
+			if err != nil {
+				continue
+			}
+
+			switch event.Type {
+			case watch.Error:
+				e.logger.Info("store watch failed", "res", res, "status", event.Object)
+				return isSynced
+			case watch.Bookmark:
+				if !isSynced && accessor.GetAnnotations()[cluster.InitialEventsEndAnnotation] == "true" {
+					isSynced = true
+
+					if synced != nil {
+						synced <- struct{}{}
+					}
+				}
+			case watch.Added, watch.Modified, watch.Deleted:
+				var changed bool
+				if res == pods {
+					changed = e.updatePodLabels(event.Type, accessor)
+				} else {
+					changed = e.updateSelector(res, event.Type, event.Object, fields)
+				}
+
+				if changed {
+					e.invalidate(accessor.GetNamespace())
+				}
+			}
+		}
+	}
+}
+
+// updatePodLabels records the labels of a pod. It returns true if the pod
+// was added or deleted, or its labels changed.
+func (e *Engine) updatePodLabels(eventType watch.EventType, pod metav1.Object) bool {
+	key := types.NamespacedName{Namespace: pod.GetNamespace(), Name: pod.GetName()}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if eventType == watch.Deleted {
+		delete(e.podLabels, key)
+		return true
+	}
+
+	current := labels.Set(pod.GetLabels())
+	previous, ok := e.podLabels[key]
+	e.podLabels[key] = current
+
+	return !ok || !labels.Equals(previous, current)
+}
+
+// updateSelector records the selector fields of a selecting object or
+// workload. It returns true if the object was added or deleted, or its
+// selector changed, so changes like status updates are ignored.
+func (e *Engine) updateSelector(
+	res schema.GroupVersionResource,
+	eventType watch.EventType,
+	object runtime.Object,
+	fields []string) bool {
+	u, ok := object.(*unstructured.Unstructured)
+	if !ok {
+		return true
+	}
+
+	key := objectKey{
+		res:            res,
+		NamespacedName: types.NamespacedName{Namespace: u.GetNamespace(), Name: u.GetName()},
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if eventType == watch.Deleted {
+		delete(e.selectors, key)
+		return true
+	}
+
+	current, _, _ := unstructured.NestedFieldCopy(u.Object, fields...)
+	previous, ok := e.selectors[key]
+	e.selectors[key] = current
+
+	return !ok || !equality.Semantic.DeepEqual(previous, current)
+}
+
+// selectorFields returns the fields of an object in a resource that
+// determine the pods it selects. For a horizontal pod autoscaler, that is
+// the workload it targets.
+func (e *Engine) selectorFields(res schema.GroupVersionResource) []string {
+	for kind, r := range e.resources {
+		if r != res {
+			continue
+		}
+
+		switch kind {
+		case NetworkPolicy:
+			return []string{"spec", "podSelector"}
+		case HorizontalPodAutoscaler:
+			return []string{"spec", "scaleTargetRef"}
+		}
+	}
+
+	return []string{"spec", "selector"}
+}
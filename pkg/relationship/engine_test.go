@@ -0,0 +1,307 @@
+package relationship
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/bryanl/clientkube/pkg/clientkube"
+)
+
+var (
+	services    = schema.GroupVersionResource{Version: "v1", Resource: "services"}
+	pdbs        = schema.GroupVersionResource{Group: "policy", Version: "v1beta1", Resource: "poddisruptionbudgets"}
+	policies    = schema.GroupVersionResource{Group: "networking.k8s.io", Version: "v1", Resource: "networkpolicies"}
+	hpas        = schema.GroupVersionResource{Group: "autoscaling", Version: "v1", Resource: "horizontalpodautoscalers"}
+	deployments = schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+)
+
+func newObject(kind, name string, labels map[string]string, spec map[string]interface{}) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	u.SetAPIVersion("v1")
+	u.SetKind(kind)
+	u.SetNamespace("default")
+	u.SetName(name)
+	u.SetLabels(labels)
+
+	if spec != nil {
+		u.Object["spec"] = spec
+	}
+
+	return u
+}
+
+func names(list ...string) []types.NamespacedName {
+	var out []types.NamespacedName
+	for _, name := range list {
+		out = append(out, types.NamespacedName{Namespace: "default", Name: name})
+	}
+	return out
+}
+
+func newTestStore() *clientkube.MemoryStore {
+	store := clientkube.NewMemoryStore()
+
+	store.Add(pods, newObject("Pod", "web1", map[string]string{"app": "web", "tier": "frontend"}, nil))
+	store.Add(pods, newObject("Pod", "web2", map[string]string{"app": "web"}, nil))
+	store.Add(pods, newObject("Pod", "db", map[string]string{"app": "db"}, nil))
+
+	store.Add(services, newObject("Service", "web", nil, map[string]interface{}{
+		"selector": map[string]interface{}{"app": "web"},
+	}))
+	store.Add(services, newObject("Service", "external", nil, map[string]interface{}{}))
+
+	store.Add(pdbs, newObject("PodDisruptionBudget", "db", nil, map[string]interface{}{
+		"selector": map[string]interface{}{
+			"matchExpressions": []interface{}{
+				map[string]interface{}{"key": "app", "operator": "In", "values": []interface{}{"db"}},
+			},
+		},
+	}))
+	store.Add(pdbs, newObject("PodDisruptionBudget", "empty", nil, map[string]interface{}{
+		"selector": map[string]interface{}{},
+	}))
+
+	store.Add(policies, newObject("NetworkPolicy", "all", nil, map[string]interface{}{
+		"podSelector": map[string]interface{}{},
+	}))
+	store.Add(policies, newObject("NetworkPolicy", "frontend", nil, map[string]interface{}{
+		"podSelector": map[string]interface{}{
+			"matchLabels": map[string]interface{}{"tier": "frontend"},
+		},
+	}))
+
+	store.Add(deployments, newObject("Deployment", "web", nil, map[string]interface{}{
+		"selector": map[string]interface{}{
+			"matchLabels": map[string]interface{}{"app": "web"},
+		},
+	}))
+	store.Add(hpas, newObject("HorizontalPodAutoscaler", "web", nil, map[string]interface{}{
+		"scaleTargetRef": map[string]interface{}{
+			"apiVersion": "apps/v1",
+			"kind":       "Deployment",
+			"name":       "web",
+		},
+	}))
+	store.Add(hpas, newObject("HorizontalPodAutoscaler", "missing", nil, map[string]interface{}{
+		"scaleTargetRef": map[string]interface{}{
+			"apiVersion": "apps/v1",
+			"kind":       "Deployment",
+			"name":       "missing",
+		},
+	}))
+
+	return store
+}
+
+func TestEngine_Pods(t *testing.T) {
+	tests := []struct {
+		name       string
+		kind       Kind
+		objectName string
+		wanted     []types.NamespacedName
+		wantErr    bool
+	}{
+		{
+			name:       "service",
+			kind:       Service,
+			objectName: "web",
+			wanted:     names("web1", "web2"),
+		},
+		{
+			name:       "service without a selector",
+			kind:       Service,
+			objectName: "external",
+		},
+		{
+			name:       "pod disruption budget",
+			kind:       PodDisruptionBudget,
+			objectName: "db",
+			wanted:     names("db"),
+		},
+		{
+			name:       "pod disruption budget with an empty selector",
+			kind:       PodDisruptionBudget,
+			objectName: "empty",
+		},
+		{
+			name:       "network policy with an empty selector",
+			kind:       NetworkPolicy,
+			objectName: "all",
+			wanted:     names("db", "web1", "web2"),
+		},
+		{
+			name:       "network policy",
+			kind:       NetworkPolicy,
+			objectName: "frontend",
+			wanted:     names("web1"),
+		},
+		{
+			name:       "horizontal pod autoscaler",
+			kind:       HorizontalPodAutoscaler,
+			objectName: "web",
+			wanted:     names("web1", "web2"),
+		},
+		{
+			name:       "horizontal pod autoscaler without a target",
+			kind:       HorizontalPodAutoscaler,
+			objectName: "missing",
+		},
+		{
+			name:       "unknown kind",
+			kind:       Kind("Unknown"),
+			objectName: "web",
+			wantErr:    true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			e := NewEngine(newTestStore())
+			require.NoError(t, e.Start(context.Background()))
+			defer e.Stop()
+
+			actual, err := e.Pods(test.kind, "default", test.objectName)
+			if test.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, test.wanted, actual)
+		})
+	}
+}
+
+func TestEngine_Selecting(t *testing.T) {
+	tests := []struct {
+		name    string
+		kind    Kind
+		podName string
+		wanted  []types.NamespacedName
+	}{
+		{
+			name:    "services",
+			kind:    Service,
+			podName: "web1",
+			wanted:  names("web"),
+		},
+		{
+			name:    "pod disruption budgets",
+			kind:    PodDisruptionBudget,
+			podName: "db",
+			wanted:  names("db"),
+		},
+		{
+			name:    "network policies",
+			kind:    NetworkPolicy,
+			podName: "web1",
+			wanted:  names("all", "frontend"),
+		},
+		{
+			name:    "horizontal pod autoscalers",
+			kind:    HorizontalPodAutoscaler,
+			podName: "web2",
+			wanted:  names("web"),
+		},
+		{
+			name:    "pod that is not selected",
+			kind:    Service,
+			podName: "db",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			e := NewEngine(newTestStore())
+			require.NoError(t, e.Start(context.Background()))
+			defer e.Stop()
+
+			actual, err := e.Selecting(test.kind, "default", test.podName)
+			require.NoError(t, err)
+			require.Equal(t, test.wanted, actual)
+		})
+	}
+}
+
+func TestEngine_invalidate(t *testing.T) {
+	store := newTestStore()
+
+	e := NewEngine(store)
+	require.NoError(t, e.Start(context.Background()))
+	defer e.Stop()
+
+	actual, err := e.Pods(Service, "default", "web")
+	require.NoError(t, err)
+	require.Equal(t, names("web1", "web2"), actual)
+
+	// the pod's labels change, so it is no longer selected.
+	store.Update(pods, newObject("Pod", "web2", map[string]string{"app": "api"}, nil))
+	require.Eventually(t, func() bool {
+		actual, err := e.Pods(Service, "default", "web")
+		return err == nil && len(actual) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	// the service's selector changes.
+	store.Update(services, newObject("Service", "web", nil, map[string]interface{}{
+		"selector": map[string]interface{}{"app": "api"},
+	}))
+	require.Eventually(t, func() bool {
+		actual, err := e.Selecting(Service, "default", "web2")
+		return err == nil && len(actual) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	// the workload targeted by the autoscaler changes.
+	store.Update(deployments, newObject("Deployment", "web", nil, map[string]interface{}{
+		"selector": map[string]interface{}{
+			"matchLabels": map[string]interface{}{"app": "db"},
+		},
+	}))
+	require.Eventually(t, func() bool {
+		actual, err := e.Pods(HorizontalPodAutoscaler, "default", "web")
+		return err == nil && len(actual) == 1 && actual[0].Name == "db"
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestEngine_invalidate_statusUpdate(t *testing.T) {
+	store := newTestStore()
+
+	e := NewEngine(store)
+	require.NoError(t, e.Start(context.Background()))
+	defer e.Stop()
+
+	_, err := e.Pods(Service, "default", "web")
+	require.NoError(t, err)
+
+	e.mu.Lock()
+	generation := e.generations["default"]
+	e.mu.Unlock()
+
+	// only the service's status changes.
+	service := newObject("Service", "web", nil, map[string]interface{}{
+		"selector": map[string]interface{}{"app": "web"},
+	})
+	service.Object["status"] = map[string]interface{}{
+		"loadBalancer": map[string]interface{}{},
+	}
+	store.Update(services, service)
+
+	// a service added in another namespace shows the update was seen.
+	other := newObject("Service", "other", nil, nil)
+	other.SetNamespace("other")
+	store.Add(services, other)
+
+	require.Eventually(t, func() bool {
+		e.mu.Lock()
+		defer e.mu.Unlock()
+		return e.generations["other"] > 0
+	}, time.Second, 10*time.Millisecond)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	require.Equal(t, generation, e.generations["default"])
+	require.Contains(t, e.caches, "default")
+}
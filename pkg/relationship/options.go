@@ -0,0 +1,75 @@
+package relationship
+
+import (
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/go-logr/logr/testing"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+type options struct {
+	logger        logr.Logger
+	retryInterval time.Duration
+	resources     map[Kind]schema.GroupVersionResource
+	workloads     map[string]schema.GroupVersionResource
+}
+
+func currentOptions(list ...Option) options {
+	opts := options{
+		logger:        &testing.NullLogger{},
+		retryInterval: time.Second,
+		resources: map[Kind]schema.GroupVersionResource{
+			Service:                 {Version: "v1", Resource: "services"},
+			PodDisruptionBudget:     {Group: "policy", Version: "v1beta1", Resource: "poddisruptionbudgets"},
+			NetworkPolicy:           {Group: "networking.k8s.io", Version: "v1", Resource: "networkpolicies"},
+			HorizontalPodAutoscaler: {Group: "autoscaling", Version: "v1", Resource: "horizontalpodautoscalers"},
+		},
+		workloads: map[string]schema.GroupVersionResource{
+			"Deployment":            {Group: "apps", Version: "v1", Resource: "deployments"},
+			"ReplicaSet":            {Group: "apps", Version: "v1", Resource: "replicasets"},
+			"StatefulSet":           {Group: "apps", Version: "v1", Resource: "statefulsets"},
+			"ReplicationController": {Version: "v1", Resource: "replicationcontrollers"},
+		},
+	}
+
+	for _, o := range list {
+		o(&opts)
+	}
+
+	return opts
+}
+
+// Option configures an Engine.
+type Option func(o *options)
+
+// WithLogger sets the logger.
+func WithLogger(logger logr.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+// WithRetryInterval sets how long an engine waits before watching a
+// resource again after its store watch failed.
+func WithRetryInterval(interval time.Duration) Option {
+	return func(o *options) {
+		o.retryInterval = interval
+	}
+}
+
+// WithResource sets the group/version/resource the objects of a kind are
+// stored in, e.g. policy/v1 for pod disruption budgets.
+func WithResource(kind Kind, res schema.GroupVersionResource) Option {
+	return func(o *options) {
+		o.resources[kind] = res
+	}
+}
+
+// WithWorkload sets the group/version/resource of a workload kind that
+// horizontal pod autoscalers can target.
+func WithWorkload(kind string, res schema.GroupVersionResource) Option {
+	return func(o *options) {
+		o.workloads[kind] = res
+	}
+}
@@ -0,0 +1,103 @@
+package relationship
+
+import (
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// podSelector returns the pod selector of an object of a kind, or nil if it
+// selects no pods. res is the resource the object is stored in.
+func (e *Engine) podSelector(kind Kind, res schema.GroupVersionResource, object *unstructured.Unstructured) (labels.Selector, error) {
+	switch kind {
+	case Service:
+		// a service without a selector has its endpoints managed elsewhere.
+		return mapSelector(object, "spec", "selector")
+	case PodDisruptionBudget:
+		// an empty selector selects no pods in policy/v1beta1, and all pods
+		// in later versions.
+		return labelSelector(object, res.Version != "v1beta1", "spec", "selector")
+	case NetworkPolicy:
+		return labelSelector(object, true, "spec", "podSelector")
+	case HorizontalPodAutoscaler:
+		return e.workloadSelector(object)
+	default:
+		return nil, fmt.Errorf("unknown kind %q", kind)
+	}
+}
+
+// workloadSelector returns the pod selector of the workload targeted by a
+// horizontal pod autoscaler, or nil if the workload isn't in the store.
+func (e *Engine) workloadSelector(hpa *unstructured.Unstructured) (labels.Selector, error) {
+	kind, _, err := unstructured.NestedString(hpa.Object, "spec", "scaleTargetRef", "kind")
+	if err != nil {
+		return nil, err
+	}
+
+	name, _, err := unstructured.NestedString(hpa.Object, "spec", "scaleTargetRef", "name")
+	if err != nil {
+		return nil, err
+	}
+
+	res, ok := e.workloads[kind]
+	if !ok {
+		return nil, nil
+	}
+
+	workload, ok := e.store.Get(res, hpa.GetNamespace(), name)
+	if !ok {
+		return nil, nil
+	}
+
+	if kind == "ReplicationController" {
+		return mapSelector(workload, "spec", "selector")
+	}
+
+	return labelSelector(workload, false, "spec", "selector")
+}
+
+// mapSelector returns the selector in a map of labels, or nil if the map
+// is empty.
+func mapSelector(object *unstructured.Unstructured, fields ...string) (labels.Selector, error) {
+	m, _, err := unstructured.NestedStringMap(object.Object, fields...)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(m) == 0 {
+		return nil, nil
+	}
+
+	return labels.SelectorFromSet(m), nil
+}
+
+// labelSelector returns the selector in a label selector. A missing or
+// empty selector selects every pod if emptySelectsAll is true, and no pods
+// otherwise.
+func labelSelector(object *unstructured.Unstructured, emptySelectsAll bool, fields ...string) (labels.Selector, error) {
+	m, _, err := unstructured.NestedMap(object.Object, fields...)
+	if err != nil {
+		return nil, err
+	}
+
+	var selector metav1.LabelSelector
+	if m != nil {
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(m, &selector); err != nil {
+			return nil, fmt.Errorf("convert label selector: %w", err)
+		}
+	}
+
+	if len(selector.MatchLabels) == 0 && len(selector.MatchExpressions) == 0 {
+		if emptySelectsAll {
+			return labels.Everything(), nil
+		}
+
+		return nil, nil
+	}
+
+	return metav1.LabelSelectorAsSelector(&selector)
+}
@@ -0,0 +1,168 @@
+// Package typed reads objects from a cluster client into the concrete types
+// registered in a runtime.Scheme.
+package typed
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/bryanl/clientkube/pkg/cluster"
+)
+
+// Client gets, lists, and watches objects as the types registered in a
+// scheme. A resource is resolved to its kind with the client's resources,
+// so custom resources work once their types are added to the scheme. The
+// kinds are cached, and refreshed when a resource isn't found.
+type Client struct {
+	client cluster.Client
+	scheme *runtime.Scheme
+
+	kinds map[schema.GroupVersionResource]schema.GroupVersionKind
+	mu    sync.Mutex
+}
+
+// NewClient creates an instance of Client. client can be an informer, so
+// objects are read from its store.
+func NewClient(client cluster.Client, scheme *runtime.Scheme) *Client {
+	c := Client{
+		client: client,
+		scheme: scheme,
+		kinds:  map[schema.GroupVersionResource]schema.GroupVersionKind{},
+	}
+
+	return &c
+}
+
+// Get gets an object by name, e.g. a *corev1.Pod for pods. Subresources
+// aren't supported, since their kind can differ from the resource's kind,
+// e.g. a Scale for deployments/scale.
+func (c *Client) Get(
+	ctx context.Context,
+	res schema.GroupVersionResource,
+	name string,
+	options cluster.GetOptions) (runtime.Object, error) {
+	if options.Subresource != "" {
+		return nil, fmt.Errorf("get %s/%s: subresources aren't supported by the typed client", res.Resource, options.Subresource)
+	}
+
+	gvk, err := c.kindFor(res)
+	if err != nil {
+		return nil, err
+	}
+
+	u, err := c.client.Get(ctx, res, name, options)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.convert(gvk, u.UnstructuredContent())
+}
+
+// List lists objects as the list type of the resource's kind, e.g. a
+// *corev1.PodList for pods.
+func (c *Client) List(
+	ctx context.Context,
+	res schema.GroupVersionResource,
+	options cluster.ListOptions) (runtime.Object, error) {
+	gvk, err := c.kindFor(res)
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := c.client.List(ctx, res, options)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.convert(gvk.GroupVersion().WithKind(gvk.Kind+"List"), list.UnstructuredContent())
+}
+
+// Watch watches objects. The objects in the watch events are converted to
+// the resource's kind. An object that can't be converted is reported with
+// an error event.
+func (c *Client) Watch(
+	ctx context.Context,
+	res schema.GroupVersionResource,
+	options cluster.ListOptions) (cluster.Watch, error) {
+	gvk, err := c.kindFor(res)
+	if err != nil {
+		return nil, err
+	}
+
+	w, err := c.client.Watch(ctx, res, options)
+	if err != nil {
+		return nil, err
+	}
+
+	return newTypedWatch(w, func(u *unstructured.Unstructured) (runtime.Object, error) {
+		return c.convert(gvk, u.UnstructuredContent())
+	}), nil
+}
+
+// kindFor resolves a resource to its kind. It returns an error if the
+// resource isn't in the cluster or its kind isn't in the scheme.
+func (c *Client) kindFor(res schema.GroupVersionResource) (schema.GroupVersionKind, error) {
+	c.mu.Lock()
+	gvk, ok := c.kinds[res]
+	c.mu.Unlock()
+
+	if !ok {
+		var err error
+		gvk, ok, err = c.refreshKinds(res)
+		if err != nil {
+			return schema.GroupVersionKind{}, err
+		}
+
+		if !ok {
+			return schema.GroupVersionKind{}, fmt.Errorf("resource %s not found", res)
+		}
+	}
+
+	if !c.scheme.Recognizes(gvk) {
+		return schema.GroupVersionKind{}, fmt.Errorf("kind %s is not registered in the scheme", gvk)
+	}
+
+	return gvk, nil
+}
+
+// refreshKinds reads the kinds of the client's resources again, and returns
+// the kind of res if it is found.
+func (c *Client) refreshKinds(res schema.GroupVersionResource) (schema.GroupVersionKind, bool, error) {
+	resources, err := c.client.Resources()
+	if err != nil {
+		return schema.GroupVersionKind{}, false, fmt.Errorf("get resources: %w", err)
+	}
+
+	kinds := map[schema.GroupVersionResource]schema.GroupVersionKind{}
+	for _, r := range resources {
+		kinds[r.GroupVersionResource()] = r.GroupVersionKind()
+	}
+
+	c.mu.Lock()
+	c.kinds = kinds
+	c.mu.Unlock()
+
+	gvk, ok := kinds[res]
+	return gvk, ok, nil
+}
+
+// convert converts unstructured content to a new object of a kind.
+func (c *Client) convert(gvk schema.GroupVersionKind, content map[string]interface{}) (runtime.Object, error) {
+	object, err := c.scheme.New(gvk)
+	if err != nil {
+		return nil, fmt.Errorf("create %s: %w", gvk, err)
+	}
+
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(content, object); err != nil {
+		return nil, fmt.Errorf("convert to %s: %w", gvk, err)
+	}
+
+	object.GetObjectKind().SetGroupVersionKind(gvk)
+
+	return object, nil
+}
@@ -0,0 +1,329 @@
+package typed
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+
+	"github.com/bryanl/clientkube/pkg/cluster"
+	"github.com/bryanl/clientkube/pkg/mocks"
+)
+
+var (
+	pods    = schema.GroupVersionResource{Version: "v1", Resource: "pods"}
+	widgets = schema.GroupVersionResource{Group: "example.com", Version: "v1", Resource: "widgets"}
+	gadgets = schema.GroupVersionResource{Group: "example.com", Version: "v1", Resource: "gadgets"}
+)
+
+// Widget is a custom resource registered with the test scheme.
+type Widget struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec WidgetSpec `json:"spec"`
+}
+
+type WidgetSpec struct {
+	Size int32 `json:"size"`
+}
+
+func (w *Widget) DeepCopyObject() runtime.Object {
+	out := *w
+	w.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	return &out
+}
+
+type resource struct {
+	gvk  schema.GroupVersionKind
+	name string
+}
+
+func (r resource) GroupVersionKind() schema.GroupVersionKind { return r.gvk }
+func (r resource) GroupVersionResource() schema.GroupVersionResource {
+	return r.gvk.GroupVersion().WithResource(r.name)
+}
+func (r resource) Verbs() []string        { return []string{"get", "list", "watch"} }
+func (r resource) Name() string           { return r.name }
+func (r resource) Categories() []string   { return nil }
+func (r resource) IsNamespaced() bool     { return true }
+func (r resource) ShortNames() []string   { return nil }
+func (r resource) SingularName() string   { return "" }
+func (r resource) Subresources() []string { return nil }
+
+func newScheme(t *testing.T) *runtime.Scheme {
+	scheme := runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(scheme))
+	scheme.AddKnownTypes(widgets.GroupVersion(), &Widget{})
+	return scheme
+}
+
+func newClient(ctrl *gomock.Controller) *mocks.MockClient {
+	client := mocks.NewMockClient(ctrl)
+	client.EXPECT().Resources().Return(cluster.Resources{
+		resource{gvk: corev1.SchemeGroupVersion.WithKind("Pod"), name: "pods"},
+		resource{gvk: widgets.GroupVersion().WithKind("Widget"), name: "widgets"},
+		resource{gvk: gadgets.GroupVersion().WithKind("Gadget"), name: "gadgets"},
+	}, nil).AnyTimes()
+	return client
+}
+
+func newPod(name string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Pod",
+			"metadata": map[string]interface{}{
+				"name":      name,
+				"namespace": "default",
+			},
+			"spec": map[string]interface{}{
+				"nodeName": "node",
+			},
+		},
+	}
+}
+
+func TestClient_Get(t *testing.T) {
+	tests := []struct {
+		name       string
+		res        schema.GroupVersionResource
+		object     *unstructured.Unstructured
+		wanted     runtime.Object
+		wantErr    bool
+		skipClient bool
+	}{
+		{
+			name:   "built in type",
+			res:    pods,
+			object: newPod("pod"),
+			wanted: &corev1.Pod{
+				TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Pod"},
+				ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "default"},
+				Spec:       corev1.PodSpec{NodeName: "node"},
+			},
+		},
+		{
+			name: "custom resource",
+			res:  widgets,
+			object: &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"apiVersion": "example.com/v1",
+					"kind":       "Widget",
+					"metadata": map[string]interface{}{
+						"name":      "widget",
+						"namespace": "default",
+					},
+					"spec": map[string]interface{}{
+						"size": int64(3),
+					},
+				},
+			},
+			wanted: &Widget{
+				TypeMeta:   metav1.TypeMeta{APIVersion: "example.com/v1", Kind: "Widget"},
+				ObjectMeta: metav1.ObjectMeta{Name: "widget", Namespace: "default"},
+				Spec:       WidgetSpec{Size: 3},
+			},
+		},
+		{
+			name: "object that can't be converted",
+			res:  pods,
+			object: &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"apiVersion": "v1",
+					"kind":       "Pod",
+					"metadata": map[string]interface{}{
+						"name": "pod",
+					},
+					"spec": "invalid",
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name:       "kind not in scheme",
+			res:        gadgets,
+			object:     newPod("gadget"),
+			wantErr:    true,
+			skipClient: true,
+		},
+		{
+			name:       "resource not in cluster",
+			res:        schema.GroupVersionResource{Version: "v1", Resource: "missing"},
+			object:     newPod("missing"),
+			wantErr:    true,
+			skipClient: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			client := newClient(ctrl)
+			if !test.skipClient {
+				client.EXPECT().
+					Get(gomock.Any(), test.res, test.object.GetName(), cluster.GetOptions{Namespace: "default"}).
+					Return(test.object, nil)
+			}
+
+			c := NewClient(client, newScheme(t))
+
+			actual, err := c.Get(context.Background(), test.res, test.object.GetName(), cluster.GetOptions{Namespace: "default"})
+			if test.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, test.wanted, actual)
+		})
+	}
+}
+
+func TestClient_Get_cachesKinds(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	client := mocks.NewMockClient(ctrl)
+	client.EXPECT().Resources().Return(cluster.Resources{
+		resource{gvk: corev1.SchemeGroupVersion.WithKind("Pod"), name: "pods"},
+	}, nil).Times(1)
+	client.EXPECT().
+		Get(gomock.Any(), pods, "pod", cluster.GetOptions{Namespace: "default"}).
+		Return(newPod("pod"), nil).
+		Times(3)
+
+	c := NewClient(client, newScheme(t))
+
+	for i := 0; i < 3; i++ {
+		_, err := c.Get(context.Background(), pods, "pod", cluster.GetOptions{Namespace: "default"})
+		require.NoError(t, err)
+	}
+}
+
+func TestClient_Get_refreshesKinds(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	client := mocks.NewMockClient(ctrl)
+	gomock.InOrder(
+		client.EXPECT().Resources().Return(cluster.Resources{
+			resource{gvk: corev1.SchemeGroupVersion.WithKind("Pod"), name: "pods"},
+		}, nil),
+		client.EXPECT().Resources().Return(cluster.Resources{
+			resource{gvk: corev1.SchemeGroupVersion.WithKind("Pod"), name: "pods"},
+			resource{gvk: widgets.GroupVersion().WithKind("Widget"), name: "widgets"},
+		}, nil),
+	)
+
+	widget := &unstructured.Unstructured{}
+	widget.SetAPIVersion("example.com/v1")
+	widget.SetKind("Widget")
+	widget.SetName("widget")
+
+	client.EXPECT().Get(gomock.Any(), pods, "pod", cluster.GetOptions{}).Return(newPod("pod"), nil)
+	client.EXPECT().Get(gomock.Any(), widgets, "widget", cluster.GetOptions{}).Return(widget, nil)
+
+	c := NewClient(client, newScheme(t))
+
+	_, err := c.Get(context.Background(), pods, "pod", cluster.GetOptions{})
+	require.NoError(t, err)
+
+	actual, err := c.Get(context.Background(), widgets, "widget", cluster.GetOptions{})
+	require.NoError(t, err)
+	require.IsType(t, &Widget{}, actual)
+}
+
+func TestClient_Get_subresource(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	c := NewClient(newClient(ctrl), newScheme(t))
+
+	_, err := c.Get(context.Background(), pods, "pod", cluster.GetOptions{Namespace: "default", Subresource: "status"})
+	require.Error(t, err)
+}
+
+func TestClient_List(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	list := &unstructured.UnstructuredList{
+		Items: []unstructured.Unstructured{*newPod("pod1"), *newPod("pod2")},
+	}
+	list.SetResourceVersion("10")
+
+	client := newClient(ctrl)
+	client.EXPECT().List(gomock.Any(), pods, cluster.ListOptions{}).Return(list, nil)
+
+	c := NewClient(client, newScheme(t))
+
+	actual, err := c.List(context.Background(), pods, cluster.ListOptions{})
+	require.NoError(t, err)
+
+	podList, ok := actual.(*corev1.PodList)
+	require.True(t, ok)
+	require.Equal(t, "10", podList.ResourceVersion)
+	require.Len(t, podList.Items, 2)
+	require.Equal(t, "pod2", podList.Items[1].Name)
+	require.Equal(t, "node", podList.Items[1].Spec.NodeName)
+}
+
+func TestClient_Watch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	source := watch.NewFakeWithChanSize(10, false)
+
+	client := newClient(ctrl)
+	client.EXPECT().Watch(gomock.Any(), pods, cluster.ListOptions{}).Return(source, nil)
+
+	c := NewClient(client, newScheme(t))
+
+	w, err := c.Watch(context.Background(), pods, cluster.ListOptions{})
+	require.NoError(t, err)
+
+	invalid := newPod("invalid")
+	invalid.Object["spec"] = "invalid"
+
+	source.Add(newPod("pod"))
+	source.Modify(invalid)
+
+	e := receiveEvent(t, w)
+	require.Equal(t, watch.Added, e.Type)
+	pod, ok := e.Object.(*corev1.Pod)
+	require.True(t, ok)
+	require.Equal(t, "pod", pod.Name)
+
+	e = receiveEvent(t, w)
+	require.Equal(t, watch.Error, e.Type)
+	_, ok = e.Object.(*metav1.Status)
+	require.True(t, ok)
+
+	w.Stop()
+	w.Stop()
+
+	_, ok = <-w.ResultChan()
+	require.False(t, ok)
+	require.True(t, source.IsStopped())
+}
+
+func receiveEvent(t *testing.T, w cluster.Watch) watch.Event {
+	select {
+	case e, ok := <-w.ResultChan():
+		require.True(t, ok, "result channel is closed")
+		return e
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for event")
+		return watch.Event{}
+	}
+}
@@ -0,0 +1,89 @@
+package typed
+
+import (
+	"sync"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+
+	"github.com/bryanl/clientkube/pkg/cluster"
+)
+
+// typedWatch converts the objects in the events of a watch.
+type typedWatch struct {
+	source  cluster.Watch
+	convert func(u *unstructured.Unstructured) (runtime.Object, error)
+
+	ch   chan watch.Event
+	done chan struct{}
+	once sync.Once
+}
+
+var _ cluster.Watch = &typedWatch{}
+
+func newTypedWatch(source cluster.Watch, convert func(u *unstructured.Unstructured) (runtime.Object, error)) *typedWatch {
+	w := typedWatch{
+		source:  source,
+		convert: convert,
+		ch:      make(chan watch.Event),
+		done:    make(chan struct{}),
+	}
+
+	go w.run()
+
+	return &w
+}
+
+// Stop stops the watch and its source. It can be called more than once.
+func (w *typedWatch) Stop() {
+	w.once.Do(func() {
+		close(w.done)
+		w.source.Stop()
+	})
+}
+
+// ResultChan returns the converted events. It is closed when the watch is
+// stopped or its source ends.
+func (w *typedWatch) ResultChan() <-chan watch.Event {
+	return w.ch
+}
+
+func (w *typedWatch) run() {
+	defer close(w.ch)
+
+	for {
+		select {
+		case <-w.done:
+			return
+		case e, ok := <-w.source.ResultChan():
+			if !ok {
+				return
+			}
+
+			select {
+			case <-w.done:
+				return
+			case w.ch <- w.convertEvent(e):
+			}
+		}
+	}
+}
+
+// convertEvent converts the object in an event. Error events are passed
+// through, and an object that can't be converted becomes an error event.
+func (w *typedWatch) convertEvent(e watch.Event) watch.Event {
+	u, ok := e.Object.(*unstructured.Unstructured)
+	if e.Type == watch.Error || !ok {
+		return e
+	}
+
+	object, err := w.convert(u)
+	if err != nil {
+		status := apierrors.NewInternalError(err).Status()
+		return watch.Event{Type: watch.Error, Object: &status}
+	}
+
+	return watch.Event{Type: e.Type, Object: object}
+}